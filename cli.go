@@ -6,10 +6,12 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 )
 
 var (
-	ErrCliNoInpath = errors.New("expected input path")
+	ErrCliNoInpath    = errors.New("expected input path or spec file")
+	ErrCliBothInpaths = errors.New("input path and spec file are exclusive")
 )
 
 type GinapiCli struct {
@@ -45,18 +47,27 @@ func (c *GinapiCli) Parse() *GinapiCli {
 	flag.BoolVar(&c.isHelp, "h", false, "show help")
 	flag.BoolVar(&c.isVersion, "v", false, "show version")
 	flag.StringVar(&c.inpath, "i", "", "path to OpenAPI generated code as input")
+	flag.StringVar(&c.specpath, "spec", "", "path to OpenAPI file as input, without generated code")
+	flag.StringVar(&c.outpath, "o", "", "path to output, defaults to ginapi next to the input")
 	flag.StringVar(&c.rawVars, "vars", "", "server variables as JSON")
 	flag.BoolVar(&c.isGinCtx, "ctx", false, "enable `*gin.Context` as an argument")
+	flag.BoolVar(&c.isStreamBody, "stream-body", false, "pass binary request bodies as `io.Reader` instead of bytes")
+	flag.Int64Var(&c.maxBodySize, "max-body-size", 0, "maximum size in bytes of binary request bodies, 0 for unlimited")
+	flag.DurationVar(&c.heartbeat, "heartbeat", 15*time.Second, "interval of heartbeats in event streams, 0 to disable")
 	flag.StringVar(&c.ignoredTags, "ignored-tags", "", "comma-separated list of ignored tags")
+	flag.StringVar(&c.typeMappingPath, "type-mapping", "", "path to JSON file of Go types for schema types and formats")
 
 	flag.Parse()
 	return c
 }
 
 func (c *GinapiCli) validate() error {
-	if c.inpath == "" {
+	if c.inpath == "" && c.specpath == "" {
 		return ErrCliNoInpath
 	}
+	if c.inpath != "" && c.specpath != "" {
+		return ErrCliBothInpaths
+	}
 	if raw := c.rawVars; raw != "" {
 		if err := json.Unmarshal([]byte(raw), &c.vars); err != nil {
 			return err
@@ -89,7 +100,11 @@ func (c *GinapiCli) Run() (rc int) {
 		return 1
 	}
 
-	if err := c.Codegen.Run(); err != nil {
+	err := c.Codegen.Run()
+	for _, w := range c.Codegen.Warnings {
+		fmt.Println("WARNING: parser:", w)
+	}
+	if err != nil {
 		fmt.Println("ERROR: codegen:", err)
 		return 1
 	}
@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"text/template"
 )
 
@@ -19,20 +20,230 @@ package ginapi
 `
 
 	modelFileTmpl = tmplFileHeader + `
+{{with .ModelImports}}{{if or .Std .Others}}
+import (
+{{- range .Std}}
+	{{.}}
+{{- end}}
+
+{{range .Others -}}
+	{{.}}
+{{end -}}
+)
+{{end}}{{end}}
+
 {{range .Typedefs}}
-type {{.Target}} {{.Source}}
+type {{.Target}} {{if .IsAlias}}= {{end}}{{.Source}}
+{{end}}
+
+{{range .Structs}}
+{{$s := . -}}
+{{if .Comment}}// {{.Name}} {{.Comment}}{{end}}
+type {{.Name}} struct {
+{{with .XMLName -}}
+	XMLName xml.Name ` + "`json:\"-\" xml:\"{{.}}\" form:\"-\"`" + `
+{{end -}}
+{{range .Embeds -}}
+	{{.}}
+{{end -}}
+{{range .Fields -}}
+	{{with .Comment}}// {{.}}
+	{{end -}}
+	{{.Field}} {{.Type}} ` + "`json:\"{{.Tag}}\" xml:\"{{.XMLTag}}\" form:\"{{.Name}}\"`" + `
+{{end}}
+{{- with .Additional}}
+	// AdditionalProperties are the properties not declared.
+	AdditionalProperties map[string]{{.}} ` + "`json:\"-\" xml:\"-\"`" + `
+{{- end}}
+}
+
+{{if .EmbedsCodecs}}
+// MarshalJSON encodes the embedded models along with the properties, instead
+// of the promoted methods encoding the embedded models only.
+func (m {{.Name}}) MarshalJSON() ([]byte, error) {
+	type properties struct {
+{{range .Fields -}}
+		{{.Field}} {{.Type}} ` + "`json:\"{{.Tag}}\"`" + `
+{{end -}}
+	}
+	return detail.MarshalAllOf(
+{{- if .Additional}}
+		m.AdditionalProperties,
+{{- end}}
+{{- range .Embeds}}
+		m.{{.}},
+{{- end}}
+		properties{
+{{- range .Fields}}
+			{{.Field}}: m.{{.Field}},
+{{- end}}
+		},
+	)
+}
+
+// UnmarshalJSON decodes the embedded models along with the properties.
+func (m *{{.Name}}) UnmarshalJSON(data []byte) error {
+	return m.unmarshalProperties(data)
+}
+
+// unmarshalProperties decodes the embedded models along with the properties,
+// the names are declared by the models embedding this one.
+func (m *{{.Name}}) unmarshalProperties(data []byte, names ...string) error {
+	type properties struct {
+{{range .Fields -}}
+		{{.Field}} {{.Type}} ` + "`json:\"{{.Tag}}\"`" + `
+{{end -}}
+	}
+	var props properties
+	if err := json.Unmarshal(data, &props); err != nil {
+		return err
+	}
+	names = append(names{{range .Declared}}, {{printf "%q" .}}{{end}})
+{{- range .Embeds}}
+{{- if index $s.EmbedsAdditional .}}
+	if err := m.{{.}}.unmarshalProperties(data, names...); err != nil {
+{{- else}}
+	if err := json.Unmarshal(data, &m.{{.}}); err != nil {
+{{- end}}
+		return err
+	}
+{{- end}}
+{{range .Fields -}}
+	m.{{.Field}} = props.{{.Field}}
+{{end -}}
+{{if .Additional -}}
+	return detail.UnmarshalAdditional(data, &m.AdditionalProperties, names...)
+{{- else -}}
+	return nil
+{{- end}}
+}
+{{else if .Additional}}
+// MarshalJSON encodes the properties along with the additional ones.
+func (m {{.Name}}) MarshalJSON() ([]byte, error) {
+	type model {{.Name}}
+	return detail.MarshalAdditional(model(m), m.AdditionalProperties)
+}
+
+// UnmarshalJSON decodes the properties, the undeclared ones go to the
+// additional properties.
+func (m *{{.Name}}) UnmarshalJSON(data []byte) error {
+	return m.unmarshalProperties(data)
+}
+
+// unmarshalProperties decodes the properties, the ones neither declared nor in
+// the names go to the additional properties.
+func (m *{{.Name}}) unmarshalProperties(data []byte, names ...string) error {
+	type model {{.Name}}
+	if err := json.Unmarshal(data, (*model)(m)); err != nil {
+		return err
+	}
+	names = append(names{{range .Declared}}, {{printf "%q" .}}{{end}})
+	return detail.UnmarshalAdditional(data, &m.AdditionalProperties, names...)
+}
+{{end}}
+{{end}}
+
+{{range .ParamGroups}}
+{{$group := . -}}
+// {{.Name}} is the shared {{.In}} parameters.
+type {{.Name}} struct {
+{{range .Fields -}}
+	{{with .Comment}}// {{.}}
+	{{end -}}
+	{{.Field}} {{.Type}}{{if $group.TagKey}} ` + "`{{$group.TagKey}}:\"{{.Tag}}\"`" + `{{end}}
+{{end}}
+}
+{{end}}
+
+{{range .Enums}}
+{{$enum := .Name -}}
+// {{.Name}} {{with .Comment}}{{.}}{{else}}is an enum of {{.Type}}.{{end}}
+type {{.Name}} {{.Type}}
+{{if .Values}}
+const (
+{{range .Values -}}
+	{{.Name}} {{$enum}} = {{.Value}}
+{{end}}
+)
+
+// Valid reports whether the value is one of the enum values.
+func (e {{.Name}}) Valid() bool {
+	switch e {
+	case {{range $i, $v := .Values}}{{if $i}}, {{end}}{{$v.Name}}{{end}}:
+		return true
+	}
+	return false
+}
+{{end}}
+{{end}}
+
+{{range .Unions}}
+// {{.Name}} {{with .Comment}}{{.}}{{else}}is {{if .IsOneOf}}one{{else}}any{{end}} of
+	{{- range $i, $v := .Variants}}{{if $i}},{{end}} {{$v.Type}}{{end}}.{{end}}
+type {{.Name}} struct {
+{{range .Variants -}}
+	{{.Field}} *{{.Type}}
+{{end}}
+}
+
+// MarshalJSON encodes the first variant set.
+func (u {{.Name}}) MarshalJSON() ([]byte, error) {
+	switch {
+{{range .Variants -}}
+	case u.{{.Field}} != nil:
+		return json.Marshal(u.{{.Field}})
+{{end -}}
+	}
+	return []byte("null"), nil
+}
+
+// UnmarshalJSON decodes the variant{{if not .IsOneOf}}s{{end}} matched.
+func (u *{{.Name}}) UnmarshalJSON(data []byte) error {
+{{- if .Discriminator}}
+	v, err := detail.DiscriminatorValue(data, {{.Discriminator | printf "%q"}})
+	if err != nil {
+		return err
+	}
+
+	switch v {
+{{range .Variants -}}
+	case {{range $i, $v := .Values}}{{if $i}}, {{end}}{{printf "%q" $v}}{{end}}:
+		u.{{.Field}} = new({{.Type}})
+		return json.Unmarshal(data, u.{{.Field}})
+{{end -}}
+	}
+	return detail.UnknownDiscriminator(v)
+{{- else}}
+	n := 0
+{{range .Variants}}
+	var v{{.Field}} {{.Type}}
+	if err := detail.StrictUnmarshal(data, &v{{.Field}}); err == nil {
+		u.{{.Field}} = &v{{.Field}}
+		n++
+	}
+{{end}}
+{{if .IsOneOf -}}
+	return detail.CheckOneOf(n)
+{{- else -}}
+	return detail.CheckAnyOf(n)
+{{- end}}
+{{- end}}
+}
 {{end}}
 `
 
 	serviceFileTmpl = tmplFileHeader + `
 
 import (
-	"io/ioutil"
-	"net/http"
+{{- with .Imports}}
+{{- range .Std}}
+	{{.}}
+{{- end}}
 
-	"github.com/anqur/ginapi/utils/detail"
-
-	"github.com/gin-gonic/gin"
+{{range .Others -}}
+	{{.}}
+{{end -}}
+{{- end}}
 )
 
 {{range .Methods}}
@@ -40,8 +251,13 @@ import (
 {{if .PathVars}}
 // {{.Name}}PathVars is the path variables of {{.Name}}.
 type {{.Name}}PathVars struct {
+{{range .PathVarGroups -}}
+	{{.}}
+{{end -}}
 {{range .PathVars -}}
+{{if not .Group -}}
 	{{.Field}} {{.Type}}
+{{end -}}
 {{end}}
 }
 {{end}}
@@ -49,8 +265,13 @@ type {{.Name}}PathVars struct {
 {{if .Queries}}
 // {{.Name}}Queries is the query parameters of {{.Name}}.
 type {{.Name}}Queries struct {
+{{range .QueryGroups -}}
+	{{.}}
+{{end -}}
 {{range .Queries -}}
-	{{.Field}} {{.Type}} ` + "`form:\"{{.Name}}\"`" + `
+{{if not .Group -}}
+	{{.Field}} {{.Type}} ` + "`form:\"{{if or .Parser .IsText .Style}}-{{else}}{{.Name}}{{end}}\"`" + `
+{{end -}}
 {{end}}
 }
 {{end}}
@@ -58,12 +279,120 @@ type {{.Name}}Queries struct {
 {{if .Headers}}
 // {{.Name}}Headers is the header parameters of {{.Name}}.
 type {{.Name}}Headers struct {
+{{range .HeaderGroups -}}
+	{{.}}
+{{end -}}
 {{range .Headers -}}
-	{{.Field}} {{.Type}} ` + "`header:\"{{.Name}}\"`" + `
+{{if not .Group -}}
+	{{.Field}} {{.Type}} ` + "`header:\"{{if or .Parser .IsText .Style}}-{{else}}{{.Name}}{{end}}\"`" + `
+{{end -}}
+{{end}}
+}
+{{end}}
+
+{{if .Cookies}}
+// {{.Name}}Cookies is the cookie parameters of {{.Name}}.
+type {{.Name}}Cookies struct {
+{{range .CookieGroups -}}
+	{{.}}
+{{end -}}
+{{range .Cookies -}}
+{{if not .Group -}}
+	{{.Field}} {{.Type}} ` + "`cookie:\"{{.Name}}\"`" + `
+{{end -}}
+{{end}}
+}
+{{end}}
+
+{{with .Form}}
+// {{.Name}} is the multipart form of the request body.
+type {{.Name}} struct {
+{{range .Fields -}}
+	{{.Field}} {{.Type}} ` + "`form:\"{{.Name}}\"`" + `
 {{end}}
 }
 {{end}}
 
+{{with .ResponseHeaders}}
+{{template "responseHeaders" .}}
+{{end}}
+
+{{with .Events}}
+{{- $stream := .}}
+// {{.Name}} sends the events of the response, which fails once the client
+// disconnects.
+type {{.Name}} struct {
+	s *detail.EventStream
+}
+
+// Context is canceled once the client disconnects.
+func (e {{.Name}}) Context() context.Context {
+	return e.s.Context()
+}
+{{range .Events}}
+// {{.Method}} sends {{with .Name}}the ` + "`{{.}}`" + ` event{{else}}an event{{end}}.
+func (e {{$stream.Name}}) {{.Method}}(event {{.Type}}) error {
+	return e.s.Send({{printf "%q" .Name}}, event)
+}
+{{end}}
+{{end}}
+
+{{if .Responses}}
+// {{.Name}}Response is one of the documented responses of {{.Name}}.
+type {{.Name}}Response interface {
+	write{{.Name}}Response(c *gin.Context)
+}
+
+{{range .Responses}}
+{{with .Headers}}
+{{template "responseHeaders" .}}
+{{end}}
+
+type {{.Type}} struct {
+{{if not .StatusCode -}}
+	statusCode int
+{{end -}}
+{{with .Body -}}
+	body {{.}}
+{{end -}}
+{{with .Headers -}}
+	headers {{.Name}}
+{{end -}}
+}
+
+// {{.Name}} {{.Comment}}
+func {{.Name}}(
+	{{- if not .StatusCode}}statusCode int,{{end -}}
+	{{- with .Body}}body {{.}},{{end -}}
+	{{- with .Headers}}headers {{.Name}},{{end -}}
+) {{.Interface}} {
+	return {{.Type}}{
+		{{- if not .StatusCode}}statusCode: statusCode,{{end -}}
+		{{- if .Body}}body: body,{{end -}}
+		{{- if .Headers}}headers: headers,{{end -}}
+	}
+}
+
+func (r {{.Type}}) write{{.Interface}}(c *gin.Context) {
+{{if .Headers -}}
+	r.headers.write(c)
+{{end -}}
+{{if eq .Body "io.ReadCloser" -}}
+	detail.RenderStream(c, {{with .StatusCode}}{{.}}{{else}}r.statusCode{{end}}, r.body
+		{{- range .MediaTypes}}, {{printf "%q" .}}{{end}})
+{{- else if .Body -}}
+	err := detail.Render(c, {{with .StatusCode}}{{.}}{{else}}r.statusCode{{end}}, r.body
+		{{- range .MediaTypes}}, {{printf "%q" .}}{{end}})
+	if err != nil {
+		panic(err)
+	}
+{{- else -}}
+	c.Status({{with .StatusCode}}{{.}}{{else}}r.statusCode{{end}})
+{{- end}}
+}
+{{end}}
+{{end}}
+
 {{end}}
 
 // {{.Name}} {{.Comment}}
@@ -75,8 +404,10 @@ type {{.Name}} interface {
 		{{- if .PathVars}}vars {{.Name}}PathVars,{{end -}}
 		{{- if .Queries}}q {{.Name}}Queries,{{end -}}
 		{{- if .Headers}}h {{.Name}}Headers,{{end -}}
+		{{- if .Cookies}}ck {{.Name}}Cookies,{{end -}}
 		{{- with .RequestBody}}req {{.}},{{end -}}
-	) {{if .Response}} ({{.Response}}, error) {{else}} error {{end}}
+		{{- with .Events}}events {{.Name}},{{end -}}
+	) {{template "returns" .}}
 {{end}}
 }
 
@@ -101,8 +432,10 @@ func (todo{{$.Name}}) {{.Name}}(
 	{{- if .PathVars}}{{.Name}}PathVars,{{end -}}
 	{{- if .Queries}}{{.Name}}Queries,{{end -}}
 	{{- if .Headers}}{{.Name}}Headers,{{end -}}
+	{{- if .Cookies}}{{.Name}}Cookies,{{end -}}
 	{{- with .RequestBody}}{{.}},{{end -}}
-) {{if .Response}} ({{.Response}}, error) {{else}} error {{end}} {
+	{{- with .Events}}{{.Name}},{{end -}}
+) {{template "returns" .}} {
 	panic("not implemented")
 }
 {{end}}
@@ -111,22 +444,72 @@ func (todo{{$.Name}}) {{.Name}}(
 func defaultHandle{{.Name}}(c *gin.Context) {
 	var err error
 
+{{with .Produces -}}
+	if _, err := detail.Negotiate(c
+		{{- range .}}, {{printf "%q" .}}{{end}}); err != nil {
+		_ = c.AbortWithError(http.StatusNotAcceptable, err)
+		return
+	}
+{{- end}}
+
 {{if .PathVars -}}
 	vars := {{.Name}}PathVars{}
 {{range .PathVars -}}
+{{if .Style -}}
+	err = detail.BindPath(c, {{.Name | printf "%q"}}, {{.Style | printf "%q"}}, {{.Explode}}, &vars.{{.Field}})
+	if err != nil {
+		panic(err)
+	}
+{{- if .IsEnum}}
+	if err := detail.ValidateEnum({{.Name | printf "%q"}}, vars.{{.Field}}); err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+{{- end}}
+{{- else -}}
+{{if .IsText -}}
+	var v{{.Field}} {{.Type}}
+	err = detail.ParamText(c, {{.Name | printf "%q"}}, &v{{.Field}})
+{{- else -}}
 	v{{.Field}}, err := detail.{{.Binder}}(c, {{.Name | printf "%q"}})
+{{- end}}
 	if err != nil {
 		panic(err)
 	}
-	vars.{{.Field}} = v{{.Field}}
+	vars.{{.Field}} = {{if .Convert}}{{.Convert}}(v{{.Field}}){{else}}v{{.Field}}{{end}}
+{{- if .IsEnum}}
+	if !vars.{{.Field}}.Valid() {
+		_ = c.AbortWithError(http.StatusBadRequest, detail.InvalidEnum({{.Name | printf "%q"}}, vars.{{.Field}}))
+		return
+	}
+{{- end}}
+{{- end}}
 {{end}}
 {{end}}
 
 {{if .Queries}}
 	q := {{.Name}}Queries{}
-	if err := c.ShouldBind(&q); err != nil {
+	if err := c.ShouldBindQuery(&q); err != nil {
 		panic(err)
 	}
+{{- range .Queries}}
+{{- if .Style}}
+	if err := detail.BindQuery(c, {{.Name | printf "%q"}}, {{.Style | printf "%q"}}, {{.Explode}}, &q.{{.Field}}{{range .Others}}, {{printf "%q" .}}{{end}}); err != nil {
+		panic(err)
+	}
+{{- else if or .Parser .IsText}}
+	if v, ok := c.GetQuery({{.Name | printf "%q"}}); ok {
+		{{- template "parse" .}}
+		q.{{.Field}} = {{if .IsOptional}}&{{end}}x
+	}
+{{- end}}
+{{- end}}
+{{- if .HasEnumQueries}}
+	if err := detail.ValidateEnums(q); err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+{{- end}}
 {{end}}
 
 {{if .Headers}}
@@ -134,23 +517,141 @@ func defaultHandle{{.Name}}(c *gin.Context) {
 	if err := c.ShouldBindHeader(&h); err != nil {
 		panic(err)
 	}
+{{- range .Headers}}
+{{- if .Style}}
+	if err := detail.BindHeader(c, {{.Name | printf "%q"}}, {{.Explode}}, &h.{{.Field}}); err != nil {
+		panic(err)
+	}
+{{- else if or .Parser .IsText}}
+	if v := c.GetHeader({{.Name | printf "%q"}}); v != "" {
+		{{- template "parse" .}}
+		h.{{.Field}} = {{if .IsOptional}}&{{end}}x
+	}
+{{- end}}
+{{- end}}
+{{- if .HasEnumHeaders}}
+	if err := detail.ValidateEnums(h); err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+{{- end}}
 {{end}}
 
-{{with .RequestBody}}
-{{if eq . "[]byte"}}
-	req, err := ioutil.ReadAll(c.Request.Body)
-	if err != nil {
+{{if .Cookies}}
+	ck := {{.Name}}Cookies{}
+{{- range .Cookies}}
+	if v, err := c.Cookie({{.Name | printf "%q"}}); err == nil {
+		{{- template "parse" .}}
+		ck.{{.Field}} = {{if .IsOptional}}&{{end}}x
+	}
+{{- if not .IsOptional}} else {
+		_ = c.AbortWithError(http.StatusBadRequest, detail.MissingParam({{.Name | printf "%q"}}))
+		return
+	}
+{{- end}}
+{{- end}}
+{{- if .HasEnumCookies}}
+	if err := detail.ValidateEnums(ck); err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+{{- end}}
+{{end}}
+
+{{with .Form}}
+	if _, err := detail.ContentType(c, "multipart/form-data"); err != nil {
+		_ = c.AbortWithError(http.StatusUnsupportedMediaType, err)
+		return
+	}
+	req := {{.Name}}{}
+{{- range .Fields}}
+{{- if or .IsFile .IsFiles}}
+	if files, err := detail.FormFiles(c, {{.Name | printf "%q"}}
+		{{- range .MediaTypes}}, {{printf "%q" .}}{{end}}); err != nil {
+		_ = c.AbortWithError(http.StatusUnsupportedMediaType, err)
+		return
+	} else if len(files) > 0 {
+{{- if .IsFiles}}
+		req.{{.Field}} = files
+{{- else if .IsStream}}
+		f, err := files[0].Open()
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		req.{{.Field}} = f
+{{- else}}
+		req.{{.Field}} = files[0]
+{{- end}}
+	}
+{{- if .IsRequired}} else {
+		_ = c.AbortWithError(http.StatusBadRequest, detail.MissingParam({{.Name | printf "%q"}}))
+		return
+	}
+{{- end}}
+{{- else if .IsJSON}}
+	if data, ok, err := detail.FormPart(c, {{.Name | printf "%q"}}); err != nil {
 		panic(err)
+	} else if ok {
+		if err := json.Unmarshal(data, &req.{{.Field}}); err != nil {
+			panic(err)
+		}
+	}
+{{- if .IsRequired}} else {
+		_ = c.AbortWithError(http.StatusBadRequest, detail.MissingParam({{.Name | printf "%q"}}))
+		return
+	}
+{{- end}}
+{{- else}}
+{{- if .IsRequired}}
+	if _, ok := c.GetPostForm({{.Name | printf "%q"}}); !ok {
+		_ = c.AbortWithError(http.StatusBadRequest, detail.MissingParam({{.Name | printf "%q"}}))
+		return
 	}
+{{- end}}
+	if err := detail.BindForm(c, {{.Name | printf "%q"}}, &req.{{.Field}}); err != nil {
+		panic(err)
+	}
+{{- end}}
+{{- end}}
+{{- if .HasEnums}}
+	if err := detail.ValidateEnums(req); err != nil {
+		_ = c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+{{- end}}
 {{else}}
-	req := {{.}}{}
-	if err := c.ShouldBind(&req); err != nil {
+{{if .RequestMediaTypes}}
+	contentType, err := detail.ContentType(c
+		{{- range .RequestMediaTypes}}, {{printf "%q" .}}{{end}})
+	if err != nil {
+		_ = c.AbortWithError(http.StatusUnsupportedMediaType, err)
+		return
+	}
+	var req {{.RequestBody}}
+	if err := detail.DecodeBody(c, contentType, &req); err != nil {
 		panic(err)
 	}
+{{else if eq .RequestBody "[]byte"}}
+{{- template "limit" .}}
+	req, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+{{- template "tooLarge" .}}
+		panic(err)
+	}
+{{else if eq .RequestBody "io.Reader"}}
+{{- template "limit" .}}
+	var req io.Reader = c.Request.Body
 {{end}}
 {{end}}
 
-	{{if .Response}}resp, err := {{else}} err = {{end}} default{{$.Name}}.{{.Name}}(
+	{{if .Events}}err = detail.ServeEvents(c, {{.Heartbeat.Milliseconds}}*time.Millisecond, func(events *detail.EventStream) error {
+		return
+	{{- else if .Responses}}resp, err :=
+	{{- else if and .Response .ResponseHeaders}}resp, rh, err :=
+	{{- else if .Response}}resp, err :=
+	{{- else if .ResponseHeaders}}rh, err :=
+	{{- else}}err ={{end}} default{{$.Name}}.{{.Name}}(
 {{if .HasGinCtx -}}
 		c,
 {{end -}}
@@ -163,27 +664,56 @@ func defaultHandle{{.Name}}(c *gin.Context) {
 {{if .Headers -}}
 		h,
 {{end -}}
+{{if .Cookies -}}
+		ck,
+{{end -}}
 {{with .RequestBody -}}
 		req,
 {{end -}}
-	)
+{{with .Events -}}
+		{{.Name}}{events},
+{{end -}}
+	){{if .Events}}
+	}){{end}}
 
 	if err != nil {
+{{- if eq .RequestBody "io.Reader"}}{{template "tooLarge" .}}{{end}}
 		panic(err)
 	}
 
-{{if .Response}}
-	c.JSON(http.StatusOK, resp)
+{{if .Events}}
+{{else if .Responses}}
+	if resp == nil {
+		panic(detail.ErrNoResponse)
+	}
+	resp.write{{.Name}}Response(c)
+{{else}}
+{{- if .ResponseHeaders}}
+	rh.write(c)
+{{- end}}
+{{if eq .Response "io.ReadCloser"}}
+	detail.RenderStream(c, http.StatusOK, resp
+		{{- range .ResponseMediaTypes}}, {{printf "%q" .}}{{end}})
+{{else if .Response}}
+	if err := detail.Render(c, http.StatusOK, resp
+		{{- range .ResponseMediaTypes}}, {{printf "%q" .}}{{end}}); err != nil {
+		panic(err)
+	}
 {{else}}
 	c.Status(http.StatusOK)
 {{end}}
+{{end}}
 }
 {{end}}
 
 func new{{.Name}}Routers(r *gin.Engine) *gin.Engine {
 	for _, registry := range default{{.Name}}Registry {
 		var handlers []gin.HandlerFunc
-
+{{if .HasSecurity}}
+		if len(registry.Security) > 0 {
+			handlers = append(handlers, detail.Authenticate(registry.Security, defaultSecuritySchemes))
+		}
+{{end}}
 		for _, h := range default{{.Name}}Handlers {
 			handlers = append(handlers, h)
 		}
@@ -195,6 +725,9 @@ func new{{.Name}}Routers(r *gin.Engine) *gin.Engine {
 		handlers = append(handlers, registry.Main)
 
 		r.Handle(registry.HttpMethod, registry.URL, handlers...)
+		if registry.HasImplicitHead {
+			r.Handle(http.MethodHead, registry.URL, handlers...)
+		}
 	}
 	return r
 }
@@ -209,11 +742,93 @@ var (
 			{{.Name | printf "%q"}}: {
 			HttpMethod: {{.HttpMethod | printf "%q"}},
 			URL: {{.Path | printf "%q"}},
+			HasImplicitHead: {{.HasImplicitHead}},
 			Main: defaultHandle{{.Name}},
+{{- with .Security}}
+			Security: []detail.SecurityRequirement{
+{{- range .}}
+				{ {{- range .Schemes}}{{printf "%q" .Name}}: { {{- range .Scopes}}{{printf "%q" .}}, {{end}} }, {{end}} },
+{{- end}}
+			},
+{{- end}}
 		},
 {{end}}
 	}
 )
+
+{{define "parse"}}
+{{- if .IsText}}
+		var x {{.Elem}}
+		if err := detail.ParseText(v, &x); err != nil {
+			panic(err)
+		}
+{{- else}}
+		y, err := detail.{{.Parser}}(v)
+		if err != nil {
+			panic(err)
+		}
+		x := {{with .Convert}}{{.}}(y){{else}}y{{end}}
+{{- end}}
+{{- end}}
+
+{{define "limit"}}
+{{- with .MaxBodySize}}
+	if err := detail.LimitBody(c, {{.}}); err != nil {
+		_ = c.AbortWithError(http.StatusRequestEntityTooLarge, err)
+		return
+	}
+{{- end}}
+{{- end}}
+
+{{define "tooLarge"}}
+{{- if .MaxBodySize}}
+		if errors.Is(err, detail.ErrBodyTooLarge) {
+			_ = c.AbortWithError(http.StatusRequestEntityTooLarge, err)
+			return
+		}
+{{- end}}
+{{- end}}
+
+{{define "returns"}}
+	{{- if .Responses}} ({{.Name}}Response, error)
+	{{- else if .Response}} ({{.Response}}, {{with .ResponseHeaders}}{{.Name}}, {{end}}error)
+	{{- else if .ResponseHeaders}} ({{.ResponseHeaders.Name}}, error)
+	{{- else}} error
+	{{- end}}
+{{- end}}
+
+{{define "responseHeaders"}}
+// {{.Name}} is the headers of the response.
+type {{.Name}} struct {
+{{range .Fields -}}
+{{if .IsCookie -}}
+	// {{.Field}} is the cookies set by the response, with their own
+	// attributes.
+{{else if .IsCookieValues -}}
+	// {{.Field}} is the values of the cookies set by the response.
+{{end -}}
+	{{.Field}} {{.Type}}
+{{end}}
+}
+
+func (h {{.Name}}) write(c *gin.Context) {
+{{range .Fields -}}
+{{if .IsCookie -}}
+	for _, cookie := range h.{{.Field}} {
+		http.SetCookie(c.Writer, cookie)
+	}
+{{else if .IsCookieValues -}}
+	if err := detail.SetCookies(c, h.{{.Field}}); err != nil {
+		panic(err)
+	}
+{{else -}}
+	if err := detail.WriteHeader(c, {{.Name | printf "%q"}}, false, h.{{.Field}}); err != nil {
+		panic(err)
+	}
+{{end -}}
+{{end -}}
+}
+{{- end}}
 `
 
 	routerFileTmpl = tmplFileHeader + `
@@ -229,6 +844,74 @@ func Initialize(r *gin.Engine) *gin.Engine {
 {{end}}
 	return r
 }
+`
+
+	securityFileTmpl = tmplFileHeader + `
+
+import (
+	"github.com/anqur/ginapi/utils/detail"
+	"github.com/gin-gonic/gin"
+)
+
+// SecurityHandler authenticates the requests by the security schemes, errors
+// fail the requests with 401, or 403 if they wrap ` + "`ginapiutil.ErrForbidden`" + `.
+type SecurityHandler interface {
+{{range .SecuritySchemes -}}
+	// {{.Method}} authenticates by the ` + "`{{.Name}}`" + ` scheme.
+	{{.Method}}(c *gin.Context, {{template "credentials" .}}) error
+{{end}}
+}
+
+// RegisterSecurityHandler registers the handler of all security schemes.
+func RegisterSecurityHandler(handler SecurityHandler) {
+	defaultSecurityHandler = handler
+}
+
+type todoSecurityHandler struct{}
+
+{{range .SecuritySchemes}}
+func (todoSecurityHandler) {{.Method}}(c *gin.Context, {{template "credentials" .}}) error {
+	panic("not implemented")
+}
+{{end}}
+
+var (
+	defaultSecurityHandler SecurityHandler = todoSecurityHandler{}
+
+	defaultSecuritySchemes = map[string]detail.Authenticator{
+{{range .SecuritySchemes -}}
+		{{printf "%q" .Name}}: func(c *gin.Context, scopes []string) error {
+{{- if eq .Kind "basic"}}
+			username, password, err := detail.BasicAuth(c)
+			if err != nil {
+				return err
+			}
+			return defaultSecurityHandler.{{.Method}}(c, username, password)
+{{- else if eq .Kind "apiKey"}}
+			key, err := detail.APIKey(c, {{printf "%q" .In}}, {{printf "%q" .Param}})
+			if err != nil {
+				return err
+			}
+			return defaultSecurityHandler.{{.Method}}(c, key)
+{{- else}}
+			token, err := detail.BearerToken(c)
+			if err != nil {
+				return err
+			}
+			return defaultSecurityHandler.{{.Method}}(c, token{{if eq .Kind "oauth2"}}, scopes{{end}})
+{{- end}}
+		},
+{{end}}
+	}
+)
+
+{{define "credentials"}}
+{{- if eq .Kind "basic"}}username, password string
+{{- else if eq .Kind "apiKey"}}key string
+{{- else if eq .Kind "oauth2"}}token string, scopes []string
+{{- else}}token string
+{{- end}}
+{{- end}}
 `
 )
 
@@ -250,16 +933,28 @@ func NewCodegen() *Codegen {
 
 func (c *Codegen) MkOutpath() error {
 	inpath := c.inpath
+	if !c.hasGeneratedCode() {
+		inpath = c.specpath
+	}
 
 	info, err := os.Stat(inpath)
 	if os.IsNotExist(err) {
 		return fmt.Errorf("%w: %s", ErrCodegenInpathNotExists, inpath)
 	}
-	if !info.IsDir() {
+	if err != nil {
+		return err
+	}
+	if c.hasGeneratedCode() && !info.IsDir() {
 		return fmt.Errorf("%w: %s", ErrCodegenInpathNotDir, inpath)
 	}
 
-	c.outpath = filepath.Join(inpath, "ginapi")
+	if c.outpath == "" {
+		if c.hasGeneratedCode() {
+			c.outpath = filepath.Join(inpath, "ginapi")
+		} else {
+			c.outpath = filepath.Join(filepath.Dir(inpath), "ginapi")
+		}
+	}
 	_ = os.MkdirAll(c.outpath, os.ModePerm)
 
 	return nil
@@ -282,13 +977,13 @@ func (c *Codegen) Generate() error {
 	if err := c.generateServices(); err != nil {
 		return err
 	}
-	if err := c.copyModels(); err != nil {
+	if err := c.generateModels(); err != nil {
 		return err
 	}
-	if err := c.generateTypedefs(); err != nil {
+	if err := c.generateRouters(); err != nil {
 		return err
 	}
-	if err := c.generateRouters(); err != nil {
+	if err := c.generateSecurity(); err != nil {
 		return err
 	}
 	return nil
@@ -304,24 +999,21 @@ func (c *Codegen) generateServices() error {
 	return nil
 }
 
-func (c *Codegen) copyModels() error {
-	for _, path := range c.Parser.modelPaths {
-		indata, err := ioutil.ReadFile(path)
-		if err != nil {
-			return err
-		}
-
-		indata = bytes.ReplaceAll(indata, []byte("package openapi"), []byte("package ginapi"))
+func (c *Codegen) generateModels() error {
+	// Inline objects are collected along with the operations.
+	sort.Slice(c.Structs, func(i, j int) bool {
+		return c.Structs[i].Name < c.Structs[j].Name
+	})
+	sort.Slice(c.Unions, func(i, j int) bool {
+		return c.Unions[i].Name < c.Unions[j].Name
+	})
+	sort.Slice(c.Enums, func(i, j int) bool {
+		return c.Enums[i].Name < c.Enums[j].Name
+	})
+	sort.Slice(c.ParamGroups, func(i, j int) bool {
+		return c.ParamGroups[i].Name < c.ParamGroups[j].Name
+	})
 
-		outpath := filepath.Join(c.outpath, filepath.Base(path))
-		if err := ioutil.WriteFile(outpath, indata, filePerm); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func (c *Codegen) generateTypedefs() error {
 	outpath := filepath.Join(c.outpath, "models.go")
 	return formattedRender("ginapi-models", modelFileTmpl, outpath, c.Parser)
 }
@@ -331,6 +1023,14 @@ func (c *Codegen) generateRouters() error {
 	return formattedRender("ginapi-routers", routerFileTmpl, outpath, c.Parser)
 }
 
+func (c *Codegen) generateSecurity() error {
+	if len(c.SecuritySchemes) == 0 {
+		return nil
+	}
+	outpath := filepath.Join(c.outpath, "security.go")
+	return formattedRender("ginapi-security", securityFileTmpl, outpath, c.Parser)
+}
+
 func formattedRender(name, text, outpath string, data interface{}) error {
 	tmpl, err := template.New(name).Parse(text)
 	if err != nil {
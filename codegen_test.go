@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerate generates the code of each spec under testdata into the ginapi
+// directory next to it, then checks the generated code by `go vet`, along with
+// the router tests next to the specs.
+func TestGenerate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the go command")
+	}
+
+	specs, err := filepath.Glob(filepath.Join("testdata", "*", "spec.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wildcards never match testdata, so the packages are listed one by one.
+	var pkgs []string
+	for _, spec := range specs {
+		dir := filepath.Dir(spec)
+		pkgs = append(pkgs, "./"+filepath.ToSlash(dir)+"/...")
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			c := NewCodegen()
+			c.specpath = spec
+			c.isGinCtx = true
+			if mapping := filepath.Join(dir, "mapping.json"); fileExists(mapping) {
+				c.typeMappingPath = mapping
+			}
+			if err := c.Run(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if t.Failed() {
+		return
+	}
+
+	for _, args := range [][]string{
+		{"vet"},
+		{"test", "-count=1"},
+	} {
+		out, err := exec.Command("go", append(args, pkgs...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("go %s: %v\n%s", args[0], err, out)
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
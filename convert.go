@@ -0,0 +1,390 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	oapi "github.com/getkin/kin-openapi/openapi3"
+	"github.com/ghodss/yaml"
+)
+
+// oapiSchemaKeys are the keywords whose values are schemas, JSON Schema 2020-12
+// included.
+var oapiSchemaKeys = map[string]struct{}{
+	"schema":                {},
+	"items":                 {},
+	"additionalProperties":  {},
+	"not":                   {},
+	"contains":              {},
+	"if":                    {},
+	"then":                  {},
+	"else":                  {},
+	"propertyNames":         {},
+	"unevaluatedItems":      {},
+	"unevaluatedProperties": {},
+	"contentSchema":         {},
+}
+
+// oapiSchemaListKeys are the keywords whose values are lists of schemas.
+var oapiSchemaListKeys = map[string]struct{}{
+	"allOf":       {},
+	"oneOf":       {},
+	"anyOf":       {},
+	"prefixItems": {},
+}
+
+// oapiSchemaMapKeys are the keywords whose values are maps of schemas.
+var oapiSchemaMapKeys = map[string]struct{}{
+	"properties":        {},
+	"schemas":           {},
+	"$defs":             {},
+	"patternProperties": {},
+	"dependentSchemas":  {},
+}
+
+// oapi31OnlyKeys are the schema keywords unknown to OpenAPI 3.0, which are
+// dropped after the conversion.
+var oapi31OnlyKeys = []string{
+	"$schema", "$id", "$anchor", "$comment", "$defs", "$dynamicRef", "$dynamicAnchor",
+	"const", "examples", "contentEncoding", "contentMediaType", "contentSchema",
+	"prefixItems", "contains", "minContains", "maxContains",
+	"if", "then", "else", "propertyNames", "patternProperties",
+	"dependentRequired", "dependentSchemas", "unevaluatedItems", "unevaluatedProperties",
+}
+
+// loadSwagger loads the OpenAPI file, Swagger 2.0 and OpenAPI 3.1 documents are
+// converted to 3.0 in memory first.
+func loadSwagger(path string) (*oapi.Swagger, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if version, _ := doc["swagger"].(string); version == "2.0" {
+		return convertSwagger2(doc, path)
+	}
+
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.1") {
+		return oapi.NewSwaggerLoader().LoadSwaggerFromFile(path)
+	}
+
+	if err := downgradeOapi31(doc); err != nil {
+		return nil, err
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+	return oapi.NewSwaggerLoader().LoadSwaggerFromDataWithPath(data, &url.URL{Path: path})
+}
+
+// downgradeOapi31 converts the OpenAPI 3.1 document to 3.0, type arrays with
+// "null" become nullable, and the JSON Schema keywords are mapped to the 3.0
+// ones if possible.
+func downgradeOapi31(doc map[string]interface{}) error {
+	doc["openapi"] = "3.0.3"
+	delete(doc, "webhooks")
+	delete(doc, "jsonSchemaDialect")
+	if _, ok := doc["paths"]; !ok {
+		doc["paths"] = map[string]interface{}{}
+	}
+	if info, ok := doc["info"].(map[string]interface{}); ok {
+		delete(info, "summary")
+		if license, ok := info["license"].(map[string]interface{}); ok {
+			delete(license, "identifier")
+		}
+	}
+	return walkOapi31(doc, false)
+}
+
+func walkOapi31(v interface{}, isSchema bool) error {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if isSchema {
+			if err := downgradeOapi31Schema(x); err != nil {
+				return err
+			}
+		}
+		for k, child := range x {
+			switch k {
+			case "example", "examples", "default", "enum", "const":
+				// Values are data, not documents.
+				continue
+			}
+
+			var err error
+			if _, ok := oapiSchemaKeys[k]; ok {
+				err = walkOapi31(child, true)
+			} else if _, ok := oapiSchemaListKeys[k]; ok {
+				if list, ok := child.([]interface{}); ok {
+					for _, s := range list {
+						if err = walkOapi31(s, true); err != nil {
+							break
+						}
+					}
+				}
+			} else if _, ok := oapiSchemaMapKeys[k]; ok {
+				if m, ok := child.(map[string]interface{}); ok {
+					for _, s := range m {
+						if err = walkOapi31(s, true); err != nil {
+							break
+						}
+					}
+				}
+			} else if !isSchema {
+				err = walkOapi31(child, false)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range x {
+			if err := walkOapi31(child, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func downgradeOapi31Schema(s map[string]interface{}) error {
+	// Type arrays, where "null" makes the schema nullable, e.g.
+	// `type: [string, "null"]`.
+	if types, ok := s["type"].([]interface{}); ok {
+		var others []string
+		for _, t := range types {
+			name, ok := t.(string)
+			if !ok {
+				return fmt.Errorf("%w: type %v", ErrParserBadSpecs, t)
+			}
+			if name == "null" {
+				s["nullable"] = true
+				continue
+			}
+			others = append(others, name)
+		}
+
+		delete(s, "type")
+		switch len(others) {
+		case 0:
+		case 1:
+			s["type"] = others[0]
+		default:
+			variants := make([]interface{}, len(others))
+			for i, t := range others {
+				variants[i] = map[string]interface{}{"type": t}
+			}
+			s["oneOf"] = variants
+		}
+	} else if t, ok := s["type"].(string); ok && t == "null" {
+		delete(s, "type")
+		s["nullable"] = true
+	}
+
+	// Variants of `{type: "null"}` make the schema nullable as well.
+	for _, k := range []string{"oneOf", "anyOf"} {
+		variants, ok := s[k].([]interface{})
+		if !ok {
+			continue
+		}
+		rest := variants[:0]
+		for _, v := range variants {
+			if m, ok := v.(map[string]interface{}); ok && len(m) == 1 && m["type"] == "null" {
+				s["nullable"] = true
+				continue
+			}
+			rest = append(rest, v)
+		}
+		_, hasAllOf := s["allOf"]
+		switch {
+		case len(rest) == 0:
+			delete(s, k)
+		case len(rest) == 1 && !hasAllOf:
+			// Mostly a nullable reference.
+			delete(s, k)
+			s["allOf"] = rest
+		default:
+			s[k] = rest
+		}
+	}
+
+	if v, ok := s["const"]; ok {
+		if _, ok := s["enum"]; !ok {
+			s["enum"] = []interface{}{v}
+		}
+		if _, ok := s["type"]; !ok {
+			// Types are implied by the constants.
+			switch x := v.(type) {
+			case string:
+				s["type"] = "string"
+			case bool:
+				s["type"] = "boolean"
+			case float64:
+				s["type"] = "number"
+				if x == float64(int64(x)) {
+					s["type"] = "integer"
+				}
+			}
+		}
+	}
+	if examples, ok := s["examples"].([]interface{}); ok && len(examples) > 0 {
+		if _, ok := s["example"]; !ok {
+			s["example"] = examples[0]
+		}
+	}
+
+	for _, k := range []string{"exclusiveMinimum", "exclusiveMaximum"} {
+		if n, ok := s[k].(float64); ok {
+			s[strings.Replace(k, "exclusiveM", "m", 1)] = n
+			s[k] = true
+		}
+	}
+
+	if s["type"] == "string" {
+		if t, _ := s["contentMediaType"].(string); t == "application/octet-stream" {
+			s["format"] = "binary"
+		} else if e, _ := s["contentEncoding"].(string); e == "base64" {
+			s["format"] = "byte"
+		}
+	}
+
+	for _, k := range oapi31OnlyKeys {
+		delete(s, k)
+	}
+	return nil
+}
+
+// convertSwagger2 converts the Swagger 2.0 document by openapi2conv, with the
+// root URL from `basePath`, and the content maps from `consumes` and
+// `produces`. References are resolved relative to the path, the same as the
+// loaded 3.0 documents.
+func convertSwagger2(doc map[string]interface{}, path string) (*oapi.Swagger, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var v2 openapi2.Swagger
+	if err := json.Unmarshal(data, &v2); err != nil {
+		return nil, err
+	}
+
+	// Global `produces` is not kept by openapi2.
+	var produces []string
+	if list, ok := doc["produces"].([]interface{}); ok {
+		for _, t := range list {
+			if t, ok := t.(string); ok {
+				produces = append(produces, t)
+			}
+		}
+	}
+	if len(produces) == 0 {
+		produces = []string{mimeJSON}
+	}
+
+	// Request bodies without any `consumes` are JSON, or forms of the
+	// `formData` parameters.
+	if len(v2.Consumes) == 0 {
+		for _, item := range v2.Paths {
+			for _, op := range item.Operations() {
+				if len(op.Consumes) == 0 {
+					params := append(openapi2.Parameters{}, item.Parameters...)
+					op.Consumes = swagger2Consumes(append(params, op.Parameters...))
+				}
+			}
+		}
+	}
+
+	v3, err := openapi2conv.ToV3Swagger(&v2)
+	if err != nil {
+		return nil, fmt.Errorf("%w: Swagger 2.0: %v", ErrParserBadSpecs, err)
+	}
+	v3.Servers = swagger2Servers(&v2)
+
+	// Responses are converted as JSON only.
+	for _, resp := range v3.Components.Responses {
+		swagger2Response(resp, produces)
+	}
+	for path, item := range v2.Paths {
+		for method, op := range item.Operations() {
+			types := produces
+			if len(op.Produces) > 0 {
+				types = op.Produces
+			}
+			for _, resp := range v3.Paths[path].GetOperation(method).Responses {
+				swagger2Response(resp, types)
+			}
+		}
+	}
+
+	if err := oapi.NewSwaggerLoader().ResolveRefsIn(v3, &url.URL{Path: path}); err != nil {
+		return nil, fmt.Errorf("%w: Swagger 2.0: %v", ErrParserBadSpecs, err)
+	}
+	return v3, nil
+}
+
+func swagger2Consumes(params openapi2.Parameters) []string {
+	t := mimeJSON
+	for _, param := range params {
+		if param.In != "formData" {
+			continue
+		}
+		if param.Type == "file" {
+			return []string{mimeMultipartForm}
+		}
+		t = mimeURLEncoded
+	}
+	return []string{t}
+}
+
+// swagger2Servers returns the server of `basePath`, on the `host` by the
+// `schemes` if any.
+func swagger2Servers(v2 *openapi2.Swagger) oapi.Servers {
+	basePath := v2.BasePath
+	if basePath == "" {
+		basePath = "/"
+	}
+	if v2.Host == "" {
+		return oapi.Servers{{URL: basePath}}
+	}
+
+	schemes := v2.Schemes
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	servers := make(oapi.Servers, len(schemes))
+	for i, scheme := range schemes {
+		u := url.URL{Scheme: scheme, Host: v2.Host, Path: basePath}
+		servers[i] = &oapi.Server{URL: u.String()}
+	}
+	return servers
+}
+
+// swagger2Response rebuilds the content of the response by the media types,
+// file schemas become binaries.
+func swagger2Response(resp *oapi.ResponseRef, mediaTypes []string) {
+	if resp.Ref != "" || resp.Value == nil {
+		return
+	}
+	media := resp.Value.Content.Get(mimeJSON)
+	if media == nil || media.Schema == nil {
+		return
+	}
+
+	schema := media.Schema
+	if v := schema.Value; schema.Ref == "" && v != nil && v.Type == "file" {
+		v.Type = "string"
+		v.Format = "binary"
+	}
+	resp.Value.Content = oapi.NewContentWithSchemaRef(schema, mediaTypes)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	oapi "github.com/getkin/kin-openapi/openapi3"
+)
+
+const mimeEventStream = "text/event-stream"
+
+// EventStream is the typed sink of a `text/event-stream` response, with one
+// sending method for each event schema.
+type EventStream struct {
+	Name   string
+	Events []*Event
+}
+
+type Event struct {
+	// Name of the event, empty for the default `message` events.
+	Name   string
+	Method string
+	Type   string
+}
+
+// parseEventStream parses the event stream of the operation if any, the events
+// are the variants of a oneOf schema, or the schema itself. The stream always
+// responds 200, other responses are not generated but warned about.
+func (p *Parser) parseEventStream(method *ServiceMethod, resps oapi.Responses) (bool, error) {
+	m := method.Name
+
+	statuses := make([]string, 0, len(resps))
+	for status := range resps {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	var media *oapi.MediaType
+	var streamStatus string
+	for _, status := range statuses {
+		if media = resps[status].Value.Content.Get(mimeEventStream); media != nil {
+			streamStatus = status
+			break
+		}
+	}
+	if media == nil {
+		return false, nil
+	}
+
+	var dropped []string
+	for _, status := range statuses {
+		if status != streamStatus {
+			dropped = append(dropped, status)
+			continue
+		}
+		for t := range resps[status].Value.Content {
+			if t != mimeEventStream {
+				dropped = append(dropped, status+" "+t)
+			}
+		}
+	}
+	if len(dropped) > 0 {
+		sort.Strings(dropped)
+		p.warnf("responses of method %q not generated besides the event stream: %s",
+			m, strings.Join(dropped, ", "))
+	}
+	if media.Schema == nil {
+		return false, fmt.Errorf("%w: event stream of method %q", ErrParserNoSchema, m)
+	}
+
+	s := &EventStream{Name: m + "Events"}
+	schema := media.Schema.Value
+	if len(schema.OneOf) == 0 {
+		t, err := p.goType(media.Schema, true, m+"Event")
+		if err != nil {
+			return false, fmt.Errorf("%w: event stream of method %q: %v", ErrParserBadSpecs, m, err)
+		}
+		s.Events = append(s.Events, &Event{Method: "Send", Type: t})
+	}
+
+	// Names of the events are the discriminator mapping keys, or the schema
+	// names by default.
+	names := map[string]string{}
+	if d := schema.Discriminator; d != nil {
+		for k, ref := range d.Mapping {
+			if old, ok := names[ref]; !ok || k < old {
+				names[ref] = k
+			}
+		}
+	}
+
+	methods := map[string]struct{}{}
+	for i, variant := range schema.OneOf {
+		t, err := p.goType(variant, true, fmt.Sprintf("%sEvent%d", m, i+1))
+		if err != nil {
+			return false, fmt.Errorf("%w: event %d of method %q: %v", ErrParserBadSpecs, i+1, m, err)
+		}
+
+		e := &Event{Type: t}
+		if field := OapiVariantToGoField(t); field != "" {
+			e.Method = "Send" + field
+		}
+		if _, ok := methods[e.Method]; ok || e.Method == "" {
+			e.Method = fmt.Sprintf("SendEvent%d", i+1)
+		}
+		methods[e.Method] = struct{}{}
+
+		if ref := variant.Ref; ref != "" {
+			e.Name = names[ref]
+			if e.Name == "" {
+				parts := strings.Split(ref, "/")
+				e.Name = parts[len(parts)-1]
+			}
+		}
+
+		s.Events = append(s.Events, e)
+	}
+
+	method.Events = s
+	method.Heartbeat = p.heartbeat
+	method.ResponseMediaTypes = []string{mimeEventStream}
+	return true, nil
+}
@@ -15,8 +15,7 @@ import (
 	_ "github.com/anqur/ginapi/examples/generated/statik"
 )
 
-//go:generate docker run --rm -v $PWD:/local openapitools/openapi-generator-cli generate -i /local/petstore.yaml -g go-gin-server -o /local/generated
-//go:generate ginapi -i generated -vars {"server":"http://localhost:8088"} -ctx -ignored-tags ignored
+//go:generate ginapi -spec petstore.yaml -o generated/ginapi -vars {"server":"http://localhost:8088"} -ctx -ignored-tags ignored
 //go:generate statik -src=. -dest=./generated -include=petstore.yaml
 func main() {
 	ginapi.RegisterPetsService(
@@ -71,16 +70,16 @@ func (p *DefaultPetsService) CreatePets(_ *gin.Context, h ginapi.CreatePetsHeade
 	pet := &ginapi.Pet{
 		Id:   atomic.AddInt64(&p.c, 1),
 		Name: id,
-		Tag:  id,
+		Tag:  &id,
 	}
 	if h.XTag != nil {
-		pet.Tag = *h.XTag
+		pet.Tag = h.XTag
 	}
 	p.m.Store(id, pet)
 	return &ginapi.Result{Message: "ok"}, nil
 }
 
-func (p *DefaultPetsService) ListPets(_ *gin.Context, q ginapi.ListPetsQueries) (*ginapi.Pets, error) {
+func (p *DefaultPetsService) ListPets(_ *gin.Context, q ginapi.ListPetsQueries) (ginapi.ListPetsResponse, error) {
 	var n int32
 	var ret ginapi.Pets
 
@@ -99,20 +98,24 @@ func (p *DefaultPetsService) ListPets(_ *gin.Context, q ginapi.ListPetsQueries)
 		return true
 	})
 
-	return &ret, nil
+	// No more pages, so `x-next` is omitted.
+	return ginapi.ListPets200(&ret, ginapi.ListPets200ResponseHeaders{}), nil
 }
 
-func (p *DefaultPetsService) ShowPetById(_ *gin.Context, vars ginapi.ShowPetByIdPathVars) (*ginapi.Pet, error) {
+func (p *DefaultPetsService) ShowPetById(_ *gin.Context, vars ginapi.ShowPetByIdPathVars) (ginapi.ShowPetByIdResponse, error) {
 	pet, ok := p.m.Load(vars.PetId)
 	if ok {
-		return pet.(*ginapi.Pet), nil
+		return ginapi.ShowPetById200(pet.(*ginapi.Pet)), nil
 	}
-	return nil, fmt.Errorf("not found: %s", vars.PetId)
+	return ginapi.ShowPetByIdDefault(http.StatusNotFound, &ginapi.Result{
+		Code:    http.StatusNotFound,
+		Message: fmt.Sprintf("not found: %s", vars.PetId),
+	}), nil
 }
 
-func (p *DefaultPetsService) DeletePet(_ *gin.Context, vars ginapi.DeletePetPathVars) error {
+func (p *DefaultPetsService) DeletePet(_ *gin.Context, vars ginapi.DeletePetPathVars) (ginapi.DeletePetResponse, error) {
 	p.m.Delete(vars.PetId)
-	return nil
+	return ginapi.DeletePet204(), nil
 }
 
 func (p *DefaultPetsService) UploadFile(
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	oapi "github.com/getkin/kin-openapi/openapi3"
+)
+
+// Form is a multipart form request body, bound field by field since the parts
+// could be files, JSON documents or plain values.
+type Form struct {
+	Name   string
+	Fields []*FormField
+}
+
+type FormField struct {
+	Name       string
+	Type       string
+	Field      string
+	MediaTypes []string
+	IsEnum     bool
+	IsFile     bool
+	IsFiles    bool
+	IsStream   bool
+	IsJSON     bool
+	IsRequired bool
+}
+
+// HasEnums reports whether any field should be checked against its enum
+// values.
+func (f *Form) HasEnums() bool {
+	for _, field := range f.Fields {
+		if field.IsEnum {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) parseMultipartBody(method *ServiceMethod, media *oapi.MediaType) error {
+	m := method.Name
+
+	if media.Schema == nil {
+		return fmt.Errorf("%w: request body of method %q", ErrParserNoSchema, m)
+	}
+	schema := media.Schema.Value
+	if !IsOapiStruct(schema) {
+		return fmt.Errorf("%w: multipart form of method %q is not an object",
+			ErrParserBadRequestSchema, m)
+	}
+
+	form := &Form{Name: m + "Form"}
+
+	props := make([]string, 0, len(schema.Properties))
+	for prop := range schema.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+
+	required := map[string]struct{}{}
+	for _, prop := range schema.Required {
+		required[prop] = struct{}{}
+	}
+
+	for _, prop := range props {
+		propSchema := schema.Properties[prop]
+		v := propSchema.Value
+
+		_, isRequired := required[prop]
+		f := &FormField{
+			Name:       prop,
+			Field:      OapiPropToGoField(prop),
+			IsEnum:     IsOapiEnum(v),
+			IsRequired: isRequired,
+		}
+		if enc := media.Encoding[prop]; enc != nil && enc.ContentType != "" {
+			for _, t := range strings.Split(enc.ContentType, ",") {
+				f.MediaTypes = append(f.MediaTypes, strings.TrimSpace(t))
+			}
+		}
+		if _, err := OapiExtension(v.ExtensionProps, extGinapiStream, &f.IsStream); err != nil {
+			return fmt.Errorf("%w: form field '%s/%s': %v", ErrParserBadRequestSchema, m, prop, err)
+		}
+
+		switch {
+		case IsOapiBinary(v):
+			// Large files are kept on disk by the multipart reader, the streaming
+			// ones are opened for the service. The form is parsed as a whole, so
+			// the streaming parts are still buffered before the service runs.
+			f.IsFile = true
+			f.Type = "*multipart.FileHeader"
+			if f.IsStream {
+				f.Type = "multipart.File"
+			}
+		case v.Type == "array" && v.Items != nil && IsOapiBinary(v.Items.Value):
+			if f.IsStream {
+				return fmt.Errorf("%w: form field '%s/%s': cannot stream multiple files",
+					ErrParserBadRequestSchema, m, prop)
+			}
+			f.IsFiles = true
+			f.Type = "[]*multipart.FileHeader"
+		default:
+			// Objects and arrays of objects are JSON parts by default, parts of
+			// the other media types are bound as plain values.
+			f.IsJSON = v.Type == "object" ||
+				v.Type == "array" && v.Items != nil && v.Items.Value.Type == "object"
+			if len(f.MediaTypes) > 0 {
+				f.IsJSON = false
+				for _, t := range f.MediaTypes {
+					if isJSONMediaType(t) {
+						f.IsJSON = true
+						break
+					}
+				}
+			}
+
+			t, err := p.goType(propSchema, isRequired, form.Name+f.Field)
+			if err != nil {
+				return fmt.Errorf("%w: form field '%s/%s': %v", ErrParserBadRequestSchema, m, prop, err)
+			}
+			f.Type = t
+		}
+
+		form.Fields = append(form.Fields, f)
+	}
+
+	method.Form = form
+	method.RequestBody = form.Name
+	return nil
+}
+
+// isJSONMediaType reports whether the media type is JSON, or with the `+json`
+// suffix.
+func isJSONMediaType(t string) bool {
+	t = strings.ToLower(strings.TrimSpace(strings.Split(t, ";")[0]))
+	return t == mimeJSON || strings.HasSuffix(t, "+json")
+}
@@ -4,6 +4,8 @@ go 1.16
 
 require (
 	github.com/getkin/kin-openapi v0.49.0
+	github.com/ghodss/yaml v1.0.0
 	github.com/gin-gonic/gin v1.6.3
+	github.com/google/uuid v1.2.0
 	github.com/rakyll/statik v0.1.7
 )
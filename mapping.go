@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	extGoType       = "x-go-type"
+	extGoTypeImport = "x-go-type-import"
+)
+
+var (
+	ErrMappingBadType   = errors.New("bad Go type mapping")
+	ErrMappingBadImport = errors.New("bad Go type import")
+)
+
+var goMajorVersionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// GoTypeMapping is the entry in the type mapping file, e.g.
+//
+//	{"string:ulid": {"type": "ulid.ULID", "import": "github.com/oklog/ulid/v2"}}
+type GoTypeMapping struct {
+	Type   string    `json:"type"`
+	Import *GoImport `json:"import,omitempty"`
+}
+
+// GoImport is the import of a user-defined Go type, either a path string or an
+// object with the package name.
+type GoImport struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path"`
+}
+
+func (i *GoImport) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &i.Path); err == nil {
+		return nil
+	}
+
+	type goImport GoImport
+	return json.Unmarshal(data, (*goImport)(i))
+}
+
+// loadGoTypeMappings reads the type mapping file and registers the mappings.
+func (p *Parser) loadGoTypeMappings(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var mappings map[string]*GoTypeMapping
+	if err := json.Unmarshal(data, &mappings); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrMappingBadType, filename, err)
+	}
+
+	for key, m := range mappings {
+		if err := p.registerGoType(m.Type, m.Import); err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrMappingBadType, key, err)
+		}
+		p.goTypeMappings[key] = m.Type
+	}
+	return nil
+}
+
+// registerGoType makes the package of the user-defined type known to the
+// imports of the generated code.
+func (p *Parser) registerGoType(ty string, imp *GoImport) error {
+	if ty == "" {
+		return fmt.Errorf("%w: empty type", ErrMappingBadType)
+	}
+
+	m := goQualifierRegexp.FindStringSubmatch(ty)
+	if m == nil {
+		// Builtin or generated types.
+		return nil
+	}
+	if imp == nil || imp.Path == "" {
+		if _, ok := p.goPackages[m[1]]; ok {
+			return nil
+		}
+		return fmt.Errorf("%w: no import for %q", ErrMappingBadImport, ty)
+	}
+
+	qualifier := m[1]
+	if imp.Name != "" && imp.Name != qualifier {
+		return fmt.Errorf("%w: %q imported as %q", ErrMappingBadImport, ty, imp.Name)
+	}
+	if path, ok := p.goPackages[qualifier]; ok && path != imp.Path {
+		return fmt.Errorf("%w: %q imported from both %q and %q",
+			ErrMappingBadImport, qualifier, path, imp.Path)
+	}
+	p.goPackages[qualifier] = imp.Path
+	return nil
+}
+
+// userGoType returns the user-defined type of the schema, by the x-go-type
+// extension or the type mappings.
+func (p *Parser) userGoType(schema *openapi3.Schema) (string, error) {
+	var ty string
+	ok, err := OapiExtension(schema.ExtensionProps, extGoType, &ty)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		var imp *GoImport
+		if _, err := OapiExtension(schema.ExtensionProps, extGoTypeImport, &imp); err != nil {
+			return "", err
+		}
+		if err := p.registerGoType(ty, imp); err != nil {
+			return "", err
+		}
+		return ty, nil
+	}
+
+	if ty, ok := p.goTypeMappings[schema.Type+":"+schema.Format]; ok {
+		return ty, nil
+	}
+	return p.goTypeMappings[schema.Type], nil
+}
+
+// goImportSpec is the import declaration of the path, aliased if the
+// qualifier is not the last element of the path.
+func goImportSpec(importPath, qualifier string) string {
+	spec := fmt.Sprintf("%q", importPath)
+	if qualifier == "" || qualifier == goPackageName(importPath) {
+		return spec
+	}
+	return qualifier + " " + spec
+}
+
+// goPackageName guesses the package name by the import path.
+func goPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if goMajorVersionRegexp.MatchString(name) {
+		name = path.Base(path.Dir(importPath))
+	}
+	// E.g. "gopkg.in/yaml.v2".
+	return strings.SplitN(name, ".", 2)[0]
+}
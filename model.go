@@ -0,0 +1,672 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	oapi "github.com/getkin/kin-openapi/openapi3"
+)
+
+type Typedef struct {
+	Source  string
+	Target  string
+	IsAlias bool
+}
+
+// Struct is a model generated from an object schema, or an allOf schema with
+// the referenced schemas embedded.
+type Struct struct {
+	Name       string
+	Comment    string
+	Embeds     []string
+	Fields     []*Field
+	Additional string
+	// XMLName is the name of the root element from the XML object.
+	XMLName string
+
+	// EmbedsCodecs reports whether any embedded model has its own JSON
+	// methods, which would be promoted and encode the embedded model only.
+	EmbedsCodecs bool
+	// EmbedsAdditional is the embedded models with additional properties,
+	// which are told the properties declared by this model when decoding.
+	EmbedsAdditional map[string]bool
+	// Declared is the names of the properties, the ones of the embedded models
+	// included, which are never the additional properties.
+	Declared []string
+}
+
+type Field struct {
+	Name    string
+	Type    string
+	Field   string
+	Tag     string
+	XMLTag  string
+	Comment string
+}
+
+// Union is a model generated from a oneOf/anyOf schema, exactly one variant is
+// set for oneOf, and at least one for anyOf.
+type Union struct {
+	Name          string
+	Comment       string
+	IsOneOf       bool
+	Discriminator string
+	Variants      []*Variant
+}
+
+type Variant struct {
+	Field  string
+	Type   string
+	Values []string
+}
+
+// Enum is a named type generated from a schema with enum values, with one
+// constant for each value.
+type Enum struct {
+	Name    string
+	Comment string
+	Type    string
+	Values  []*EnumValue
+}
+
+type EnumValue struct {
+	Name  string
+	Value string
+}
+
+// ParamGroup is a struct of shared parameters from `components.parameters`,
+// one for each component unless grouped by the `x-ginapi-group` extension, and
+// embedded into the parameter structs of the operations referring to them.
+type ParamGroup struct {
+	Name   string
+	In     string
+	TagKey string
+	Fields []*Field
+
+	refs map[string]struct{}
+}
+
+// ModelImports returns the packages required by the models.
+func (p *Parser) ModelImports() *Imports {
+	imports := NewImports(p.goPackages)
+	if len(p.Unions) > 0 {
+		imports.Add("encoding/json")
+		imports.Add("github.com/anqur/ginapi/utils/detail")
+	}
+	for _, s := range p.Structs {
+		if s.Additional != "" {
+			imports.Add("encoding/json")
+			imports.Add("github.com/anqur/ginapi/utils/detail")
+			imports.AddType(s.Additional)
+		}
+		if s.EmbedsCodecs {
+			imports.Add("encoding/json")
+			imports.Add("github.com/anqur/ginapi/utils/detail")
+		}
+		if s.XMLName != "" {
+			imports.Add("encoding/xml")
+		}
+	}
+
+	for _, t := range p.Typedefs {
+		imports.AddType(t.Source)
+	}
+	for _, s := range p.Structs {
+		for _, f := range s.Fields {
+			imports.AddType(f.Type)
+		}
+	}
+	for _, u := range p.Unions {
+		for _, v := range u.Variants {
+			imports.AddType(v.Type)
+		}
+	}
+	for _, g := range p.ParamGroups {
+		for _, f := range g.Fields {
+			imports.AddType(f.Type)
+		}
+	}
+	return imports
+}
+
+func (p *Parser) parseModels(schemas oapi.Schemas) error {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schema := schemas[name]
+
+		// User-defined types are aliased, so are their methods.
+		ty, err := p.userGoType(schema.Value)
+		if err != nil {
+			return fmt.Errorf("%w: schema %q: %v", ErrParserBadSpecs, name, err)
+		}
+		if ty != "" && schema.Ref == "" {
+			target := strings.Title(name)
+			if err := p.addModel(target); err != nil {
+				return err
+			}
+			p.Typedefs = append(p.Typedefs, Typedef{
+				Source:  ty,
+				Target:  target,
+				IsAlias: true,
+			})
+			continue
+		}
+
+		if schema.Ref == "" && IsOapiStruct(schema.Value) {
+			if err := p.parseStruct(name, schema.Value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Arrays, maps, primitives and references are named types.
+		target := strings.Title(name)
+		ty, err = p.goType(schema, true, target)
+		if err != nil {
+			return fmt.Errorf("%w: schema %q: %v", ErrParserBadSpecs, name, err)
+		}
+		if strings.TrimPrefix(ty, "*") == target {
+			// Composed schemas are generated as models already, nullable
+			// ones included.
+			continue
+		}
+		if err := p.addModel(target); err != nil {
+			return err
+		}
+		p.Typedefs = append(p.Typedefs, Typedef{
+			Source: ty,
+			Target: target,
+			// Formatted types keep their methods for encoding.
+			IsAlias: IsGoQualified(ty),
+		})
+	}
+
+	return nil
+}
+
+// resolveEmbeds marks the structs embedding models with their own JSON
+// methods, i.e. unions, structs with additional properties, and the structs
+// marked here as well. Properties of the embedded models are collected too.
+func (p *Parser) resolveEmbeds() {
+	structs := map[string]*Struct{}
+	for _, s := range p.Structs {
+		structs[s.Name] = s
+	}
+	unions := map[string]*Union{}
+	for _, u := range p.Unions {
+		unions[u.Name] = u
+	}
+
+	var declare func(s *Struct, visiting map[string]struct{}) []string
+	declare = func(s *Struct, visiting map[string]struct{}) []string {
+		if s.Declared != nil {
+			return s.Declared
+		}
+		if _, ok := visiting[s.Name]; ok {
+			return nil
+		}
+		visiting[s.Name] = struct{}{}
+
+		names := map[string]struct{}{}
+		for _, f := range s.Fields {
+			names[f.Name] = struct{}{}
+		}
+		for _, e := range s.Embeds {
+			// Properties of all the variants could be there for unions.
+			embeds := []string{e}
+			if u, ok := unions[e]; ok {
+				embeds = embeds[:0]
+				for _, v := range u.Variants {
+					embeds = append(embeds, v.Type)
+				}
+			}
+			for _, t := range embeds {
+				if embedded, ok := structs[t]; ok {
+					for _, name := range declare(embedded, visiting) {
+						names[name] = struct{}{}
+					}
+				}
+			}
+		}
+
+		s.Declared = make([]string, 0, len(names))
+		for name := range names {
+			s.Declared = append(s.Declared, name)
+		}
+		sort.Strings(s.Declared)
+		return s.Declared
+	}
+	for _, s := range p.Structs {
+		declare(s, map[string]struct{}{})
+	}
+
+	codecs := map[string]struct{}{}
+	for _, u := range p.Unions {
+		codecs[u.Name] = struct{}{}
+	}
+	for _, s := range p.Structs {
+		if s.Additional != "" {
+			codecs[s.Name] = struct{}{}
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, s := range p.Structs {
+			if s.EmbedsCodecs {
+				continue
+			}
+			for _, e := range s.Embeds {
+				if _, ok := codecs[e]; ok {
+					s.EmbedsCodecs = true
+					codecs[s.Name] = struct{}{}
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	// Models with additional properties would take the properties of the
+	// models embedding them as their own.
+	for _, s := range p.Structs {
+		for _, e := range s.Embeds {
+			if embedded, ok := structs[e]; ok && (embedded.Additional != "" || embedded.EmbedsCodecs) {
+				if s.EmbedsAdditional == nil {
+					s.EmbedsAdditional = map[string]bool{}
+				}
+				s.EmbedsAdditional[e] = true
+			}
+		}
+	}
+}
+
+// addModel reserves the name of a model, names of the inline objects could
+// collide with the named ones.
+func (p *Parser) addModel(name string) error {
+	if _, ok := p.models[name]; ok {
+		return fmt.Errorf("%w: duplicate model %q", ErrParserBadSpecs, name)
+	}
+	p.models[name] = struct{}{}
+	return nil
+}
+
+// goType is the same as OapiToGoType, except that inline objects are generated
+// as models named after the given name, e.g. items of an array are named after
+// the array with the "Item" suffix.
+func (p *Parser) goType(ref *oapi.SchemaRef, required bool, name string) (ret string, err error) {
+	if ref.Ref != "" {
+		return p.oapiToGoType(ref, required)
+	}
+
+	schema := ref.Value
+	if schema.Nullable {
+		required = false
+	}
+	if ty, err := p.userGoType(schema); err != nil || ty != "" {
+		// User-defined types are never generated.
+		return p.oapiToGoType(ref, required)
+	}
+
+	switch {
+	case len(schema.Enum) > 0:
+		if err = p.parseEnum(name, schema); err != nil {
+			return
+		}
+		ret = name
+	case len(schema.OneOf) > 0 || len(schema.AnyOf) > 0:
+		if err = p.parseUnion(name, schema); err != nil {
+			return
+		}
+		ret = name
+	case len(schema.AllOf) == 1 && schema.Properties == nil:
+		// Mostly used to attach descriptions to a reference.
+		return p.goType(schema.AllOf[0], required, name)
+	case len(schema.AllOf) > 0:
+		if err = p.parseStruct(name, schema); err != nil {
+			return
+		}
+		ret = name
+	case IsOapiStruct(schema):
+		if err = p.parseStruct(name, schema); err != nil {
+			return
+		}
+		ret = name
+	case schema.Type == "array":
+		var t string
+		t, err = p.goType(schema.Items, true, name+"Item")
+		if err != nil {
+			return
+		}
+		ret = "[]" + t
+	case schema.Type == "object" && schema.AdditionalProperties != nil:
+		var t string
+		t, err = p.goType(schema.AdditionalProperties, true, name+"Value")
+		if err != nil {
+			return
+		}
+		ret = "map[string]" + t
+	default:
+		return p.oapiToGoType(ref, required)
+	}
+
+	if !required {
+		ret = "*" + ret
+	}
+
+	return
+}
+
+func (p *Parser) parseStruct(name string, schema *oapi.Schema) error {
+	s := &Struct{
+		Name:    strings.Title(name),
+		Comment: schema.Description,
+	}
+	if err := p.addModel(s.Name); err != nil {
+		return err
+	}
+	x, err := GetOapiXML(schema)
+	if err != nil {
+		return fmt.Errorf("%w: schema %q: %v", ErrParserBadSpecs, name, err)
+	}
+	s.XMLName = x.Name
+
+	properties := oapi.Schemas{}
+	required := map[string]struct{}{}
+	if err := flattenAllOf(s, schema, properties, required); err != nil {
+		return fmt.Errorf("%w: schema %q: %v", ErrParserBadSpecs, name, err)
+	}
+
+	props := make([]string, 0, len(properties))
+	for prop := range properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+
+	if HasOapiAdditionalProperties(schema) {
+		s.Additional = "interface{}"
+		if items := schema.AdditionalProperties; items != nil {
+			t, err := p.goType(items, true, s.Name+"Value")
+			if err != nil {
+				return fmt.Errorf("%w: additional properties of schema %q: %v",
+					ErrParserBadSpecs, name, err)
+			}
+			s.Additional = t
+		}
+	}
+
+	for _, prop := range props {
+		propSchema := properties[prop]
+
+		// Optional fields are pointers, the same as optional parameters.
+		_, isRequired := required[prop]
+		field := OapiPropToGoField(prop)
+		ty, err := p.goType(propSchema, isRequired, s.Name+field)
+		if err != nil {
+			return fmt.Errorf("%w: property %q of schema %q: %v",
+				ErrParserBadSpecs, prop, name, err)
+		}
+
+		tag := prop
+		if !isRequired {
+			tag += ",omitempty"
+		}
+		xmlTag, err := oapiXMLTag(prop, propSchema.Value, isRequired)
+		if err != nil {
+			return fmt.Errorf("%w: property %q of schema %q: %v",
+				ErrParserBadSpecs, prop, name, err)
+		}
+		if strings.HasPrefix(strings.TrimPrefix(ty, "*"), "map[") {
+			// Maps are not supported by the XML encoding.
+			xmlTag = "-"
+		}
+
+		f := &Field{
+			Name:   prop,
+			Type:   ty,
+			Field:  field,
+			Tag:    tag,
+			XMLTag: xmlTag,
+		}
+		if propSchema.Ref == "" {
+			// Descriptions of the references belong to the models.
+			f.Comment = propSchema.Value.Description
+		}
+		s.Fields = append(s.Fields, f)
+	}
+
+	p.Structs = append(p.Structs, s)
+	return nil
+}
+
+// oapiXMLTag returns the `xml` tag of the property, named by the XML objects of
+// the property schema and its items.
+func oapiXMLTag(prop string, schema *oapi.Schema, required bool) (string, error) {
+	x, err := GetOapiXML(schema)
+	if err != nil {
+		return "", err
+	}
+	name := prop
+	if x.Name != "" {
+		name = x.Name
+	}
+
+	if schema.Type == "array" && schema.Items != nil {
+		// Items are repeated elements, inside the wrapping one if wrapped.
+		items, err := GetOapiXML(schema.Items.Value)
+		if err != nil {
+			return "", err
+		}
+		item := name
+		if items.Name != "" {
+			item = items.Name
+		}
+		if x.Wrapped {
+			item = name + ">" + item
+		}
+		name = item
+	}
+
+	if x.Attribute {
+		name += ",attr"
+	}
+	if !required {
+		name += ",omitempty"
+	}
+	return name, nil
+}
+
+// flattenAllOf merges properties of the schema and its allOf subschemas, the
+// referenced subschemas are embedded into the struct instead.
+func flattenAllOf(s *Struct, schema *oapi.Schema, props oapi.Schemas, required map[string]struct{}) error {
+	for _, sub := range schema.AllOf {
+		if sub.Ref != "" {
+			t, err := OapiRefToGoStruct(sub.Ref)
+			if err != nil {
+				return err
+			}
+			s.Embeds = append(s.Embeds, t)
+			continue
+		}
+		if t := sub.Value.Type; t != "" && t != "object" {
+			return fmt.Errorf("%w: allOf with %s", ErrUtilBadOapiSchemaType, t)
+		}
+		if err := flattenAllOf(s, sub.Value, props, required); err != nil {
+			return err
+		}
+	}
+
+	for prop, propSchema := range schema.Properties {
+		props[prop] = propSchema
+	}
+	for _, prop := range schema.Required {
+		required[prop] = struct{}{}
+	}
+	return nil
+}
+
+func (p *Parser) parseUnion(name string, schema *oapi.Schema) error {
+	u := &Union{
+		Name:    strings.Title(name),
+		Comment: schema.Description,
+		IsOneOf: len(schema.OneOf) > 0,
+	}
+	if err := p.addModel(u.Name); err != nil {
+		return err
+	}
+
+	variants := schema.OneOf
+	if !u.IsOneOf {
+		variants = schema.AnyOf
+	}
+
+	// Values of the discriminator are the mapping keys, or the schema names by
+	// default.
+	values := map[string][]string{}
+	if d := schema.Discriminator; d != nil {
+		u.Discriminator = d.PropertyName
+
+		keys := make([]string, 0, len(d.Mapping))
+		for k := range d.Mapping {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			ref := d.Mapping[k]
+			if !strings.Contains(ref, "/") {
+				// Mapping values could be the schema names as well.
+				ref = "#/components/schemas/" + ref
+			}
+			values[ref] = append(values[ref], k)
+		}
+	}
+
+	fields := map[string]struct{}{}
+	for i, variant := range variants {
+		ty, err := p.goType(variant, true, fmt.Sprintf("%sVariant%d", u.Name, i+1))
+		if err != nil {
+			return fmt.Errorf("%w: variant %d of schema %q: %v",
+				ErrParserBadSpecs, i+1, name, err)
+		}
+
+		v := &Variant{
+			Field: OapiVariantToGoField(ty),
+			Type:  ty,
+		}
+		if _, ok := fields[v.Field]; ok || v.Field == "" {
+			v.Field = fmt.Sprintf("Variant%d", i+1)
+		}
+		fields[v.Field] = struct{}{}
+
+		if u.Discriminator != "" {
+			if variant.Ref == "" {
+				return fmt.Errorf("%w: variant %d of schema %q: discriminator requires $ref",
+					ErrParserBadSpecs, i+1, name)
+			}
+			v.Values = values[variant.Ref]
+			if len(v.Values) == 0 {
+				parts := strings.Split(variant.Ref, "/")
+				v.Values = []string{parts[len(parts)-1]}
+			}
+		}
+
+		u.Variants = append(u.Variants, v)
+	}
+
+	p.Unions = append(p.Unions, u)
+	return nil
+}
+
+// oapiEnumLiteral returns the Go literal of the enum value in the schema type.
+// Numbers of string enums are taken as strings, while booleans are not, since
+// YAML parses `y` or `on` as true as well.
+func oapiEnumLiteral(schemaType string, value interface{}) (string, bool) {
+	switch x := value.(type) {
+	case string:
+		if schemaType == "string" || schemaType == "" {
+			return strconv.Quote(x), true
+		}
+	case float64:
+		s := strconv.FormatFloat(x, 'f', -1, 64)
+		switch schemaType {
+		case "string":
+			return strconv.Quote(s), true
+		case "integer":
+			if x == math.Trunc(x) {
+				return s, true
+			}
+		case "number", "":
+			return s, true
+		}
+	case bool:
+		if schemaType == "boolean" || schemaType == "" {
+			return strconv.FormatBool(x), true
+		}
+	}
+	return "", false
+}
+
+func (p *Parser) parseEnum(name string, schema *oapi.Schema) error {
+	e := &Enum{
+		Name:    strings.Title(name),
+		Comment: schema.Description,
+	}
+	if err := p.addModel(e.Name); err != nil {
+		return err
+	}
+
+	// The underlying type is the schema without enum values, nullable enums
+	// are pointers to the enum type instead.
+	base := *schema
+	base.Nullable = false
+	ty, err := p.oapiToGoType(&oapi.SchemaRef{Value: &base}, true)
+	if err != nil {
+		return fmt.Errorf("%w: enum %q: %v", ErrParserBadSpecs, name, err)
+	}
+	e.Type = ty
+
+	var varnames []string
+	if _, err := OapiExtension(schema.ExtensionProps, "x-enum-varnames", &varnames); err != nil {
+		return fmt.Errorf("%w: enum %q: %v", ErrParserBadSpecs, name, err)
+	}
+
+	consts := map[string]struct{}{}
+	for i, value := range schema.Enum {
+		v := &EnumValue{}
+
+		if value == nil {
+			// Null is only there for nullable enums.
+			continue
+		}
+		lit, ok := oapiEnumLiteral(schema.Type, value)
+		if !ok {
+			return fmt.Errorf("%w: enum %q: bad value %#v of type %s",
+				ErrParserBadSpecs, name, value, schema.Type)
+		}
+		v.Value = lit
+
+		if i < len(varnames) {
+			v.Name = varnames[i]
+		} else {
+			v.Name = e.Name + OapiEnumToGoConst(value)
+		}
+		if _, ok := consts[v.Name]; ok {
+			v.Name += strconv.Itoa(i)
+		}
+		consts[v.Name] = struct{}{}
+
+		e.Values = append(e.Values, v)
+	}
+
+	p.Enums = append(p.Enums, e)
+	return nil
+}
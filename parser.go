@@ -9,14 +9,23 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	oapi "github.com/getkin/kin-openapi/openapi3"
 )
 
 const (
-	mimeJSON        = "application/json"
-	mimeOctetStream = "application/octet-stream"
+	mimeJSON          = "application/json"
+	mimeMultipartForm = "multipart/form-data"
+	mimeURLEncoded    = "application/x-www-form-urlencoded"
+
+	goStreamType = "io.ReadCloser"
+
+	extGinapiGroup  = "x-ginapi-group"
+	extGinapiStream = "x-ginapi-stream"
 )
 
 var (
@@ -25,10 +34,26 @@ var (
 	ErrParserNoRootUrl        = errors.New("no root URL specified")
 	ErrParserBadRootUrl       = errors.New("bad root URL specified")
 	ErrParserBadParamKind     = errors.New("bad parameter kind")
+	ErrParserBadParamStyle    = errors.New("bad parameter style")
 	ErrParserBadParamSchema   = errors.New("bad parameter schema")
 	ErrParserBadRequestSchema = errors.New("bad request body schema")
+	ErrParserBadStatus        = errors.New("bad response status")
+	ErrParserBadSecurity      = errors.New("bad security scheme")
 )
 
+// oapiHttpMethods are all the operation kinds of a path item, in the order
+// they get parsed.
+var oapiHttpMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
 type Parser struct {
 	// CLI-related info.
 
@@ -38,23 +63,42 @@ type Parser struct {
 
 	vars            map[string]string
 	isGinCtx        bool
+	isStreamBody    bool
+	maxBodySize     int64
+	heartbeat       time.Duration
 	ignoredServices map[string]struct{}
+	typeMappingPath string
 
 	// Some meta info.
 
-	rootURL    string
-	modelPaths []string
-	methods    map[string]*ServiceMethod
+	rootURL string
+	methods map[string]*ServiceMethod
 
 	// Used for template rendering, the 'true' ASTs.
 
-	Typedefs []Typedef
-	Services map[string]*ServiceInfo
-}
-
-type Typedef struct {
-	Source string
-	Target string
+	Typedefs    []Typedef
+	Structs     []*Struct
+	Unions      []*Union
+	Enums       []*Enum
+	ParamGroups []*ParamGroup
+	Services    map[string]*ServiceInfo
+
+	SecuritySchemes []*SecurityScheme
+
+	// Warnings are the parts of the specs not generated, which do not fail the
+	// generation.
+	Warnings []string
+
+	security    oapi.SecurityRequirements
+	models      map[string]struct{}
+	paramGroups map[string]*ParamGroup
+	paramTypes  map[string]string
+
+	// User-defined Go types for the schema types, keyed by "type:format", or
+	// "type" for all the formats.
+	goTypeMappings map[string]string
+	// Import paths of the packages by their qualifiers.
+	goPackages map[string]string
 }
 
 type ServiceInfo struct {
@@ -63,6 +107,144 @@ type ServiceInfo struct {
 	Var      string
 	Methods  map[string]*ServiceMethod
 	Comment  string
+
+	packages map[string]string
+}
+
+// HasSecurity reports whether any method requires authentication.
+func (s *ServiceInfo) HasSecurity() bool {
+	for _, method := range s.Methods {
+		if len(method.Security) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Imports returns the packages required by the methods.
+func (s *ServiceInfo) Imports() *Imports {
+	imports := NewImports(
+		s.packages,
+		"net/http",
+		"github.com/anqur/ginapi/utils/detail",
+		"github.com/gin-gonic/gin",
+	)
+	for _, method := range s.Methods {
+		if method.RequestBody == "[]byte" {
+			imports.Add("io/ioutil")
+		}
+		if method.MaxBodySize > 0 {
+			imports.Add("errors")
+		}
+		if s := method.Events; s != nil {
+			imports.Add("context")
+			imports.Add("time")
+			for _, e := range s.Events {
+				imports.AddType(e.Type)
+			}
+		}
+
+		// Types of the shared parameters are declared in the models, only the
+		// user-defined ones are still referred to by the handlers.
+		for _, v := range method.PathVars {
+			if v.Group == "" || v.IsText {
+				imports.AddType(v.Type)
+			}
+		}
+		for _, q := range method.Queries {
+			if q.Group == "" || q.IsText {
+				imports.AddType(q.Type)
+			}
+		}
+		for _, h := range method.Headers {
+			if h.Group == "" || h.IsText {
+				imports.AddType(h.Type)
+			}
+		}
+		for _, ck := range method.Cookies {
+			if ck.Group == "" || ck.IsText {
+				imports.AddType(ck.Type)
+			}
+		}
+		imports.AddType(method.RequestBody)
+		if method.Form != nil {
+			for _, f := range method.Form.Fields {
+				imports.AddType(f.Type)
+				if f.IsJSON {
+					imports.Add("encoding/json")
+				}
+			}
+		}
+		imports.AddType(method.Response)
+		if h := method.ResponseHeaders; h != nil {
+			for _, f := range h.Fields {
+				imports.AddType(f.Type)
+			}
+		}
+		for _, r := range method.Responses {
+			imports.AddType(r.Body)
+			if h := r.Headers; h != nil {
+				for _, f := range h.Fields {
+					imports.AddType(f.Type)
+				}
+			}
+		}
+	}
+	return imports
+}
+
+// HasEnumQueries reports whether any query parameter should be checked
+// against its enum values.
+func (m *ServiceMethod) HasEnumQueries() bool {
+	for _, q := range m.Queries {
+		if q.IsEnum {
+			return true
+		}
+	}
+	return false
+}
+
+// HasEnumHeaders reports whether any header parameter should be checked
+// against its enum values.
+func (m *ServiceMethod) HasEnumHeaders() bool {
+	for _, h := range m.Headers {
+		if h.IsEnum {
+			return true
+		}
+	}
+	return false
+}
+
+// Produces returns the media types of all the responses, for the `Accept`
+// header to be negotiated against.
+func (m *ServiceMethod) Produces() []string {
+	var ret []string
+	seen := map[string]struct{}{}
+	add := func(mediaTypes []string) {
+		for _, t := range mediaTypes {
+			if _, ok := seen[t]; !ok {
+				seen[t] = struct{}{}
+				ret = append(ret, t)
+			}
+		}
+	}
+
+	add(m.ResponseMediaTypes)
+	for _, r := range m.Responses {
+		add(r.MediaTypes)
+	}
+	return ret
+}
+
+// HasEnumCookies reports whether any cookie parameter should be checked
+// against its enum values.
+func (m *ServiceMethod) HasEnumCookies() bool {
+	for _, ck := range m.Cookies {
+		if ck.IsEnum {
+			return true
+		}
+	}
+	return false
 }
 
 type ServiceMethod struct {
@@ -70,57 +252,187 @@ type ServiceMethod struct {
 	Name     string
 	Comment  string
 
-	Path        string
-	HttpMethod  string
-	HasGinCtx   bool
-	PathVars    []*PathVar
-	Queries     []*Query
-	Headers     []*Header
-	RequestBody string
-	Response    string
+	Path            string
+	HttpMethod      string
+	HasImplicitHead bool
+	HasGinCtx       bool
+	PathVars        []*PathVar
+	Queries         []*Query
+	Headers         []*Header
+	Cookies         []*Cookie
+	PathVarGroups   []string
+	QueryGroups     []string
+	HeaderGroups    []string
+	CookieGroups    []string
+	RequestBody     string
+	Form            *Form
+	Response        string
+	ResponseHeaders *ResponseHeaders
+	Responses       []*Response
+
+	// Maximum size of the binary request body, unlimited if 0.
+	MaxBodySize int64
+
+	// Events are sent by the service to a `text/event-stream` response.
+	Events    *EventStream
+	Heartbeat time.Duration
+
+	Security []*SecurityRequirement
+
+	// Media types of the bodies decoded and encoded by codecs.
+	RequestMediaTypes  []string
+	ResponseMediaTypes []string
 }
 
 type PathVar struct {
-	Name   string
-	Type   string
-	Field  string
-	Binder string
+	Name    string
+	Type    string
+	Field   string
+	Binder  string
+	Convert string
+	IsEnum  bool
+	IsText  bool
+	Group   string
+	Style   string
+	Explode bool
 }
 
 type Query struct {
-	Name  string
-	Type  string
-	Field string
+	Name       string
+	Type       string
+	Elem       string
+	Field      string
+	IsEnum     bool
+	IsOptional bool
+	Parser     string
+	Convert    string
+	IsText     bool
+	Group      string
+	Style      string
+	Explode    bool
+	// Others are the names of the other queries, which are not the
+	// properties of the exploded form object.
+	Others []string
+
+	isFormObject bool
 }
 
 type Header struct {
-	Name  string
-	Type  string
-	Field string
+	Name       string
+	Type       string
+	Elem       string
+	Field      string
+	IsEnum     bool
+	IsOptional bool
+	Parser     string
+	Convert    string
+	IsText     bool
+	Group      string
+	Style      string
+	Explode    bool
+}
+
+// Cookie is a cookie parameter, which is always parsed by the detail package
+// since Gin does not bind cookies.
+type Cookie struct {
+	Name       string
+	Type       string
+	Elem       string
+	Field      string
+	IsEnum     bool
+	IsOptional bool
+	Parser     string
+	Convert    string
+	IsText     bool
+	Group      string
+}
+
+// Response is one of the documented responses of a method, it's only used
+// when a method documents any status other than 200.
+type Response struct {
+	Name       string
+	Type       string
+	Interface  string
+	Comment    string
+	StatusCode int
+	Body       string
+	MediaTypes []string
+	Headers    *ResponseHeaders
+}
+
+// ResponseHeaders is the headers declared by a response, returned along with
+// the body. `Set-Cookie` of strings is for the cookies as `[]*http.Cookie`,
+// with their own attributes like `Path` and `HttpOnly`. `Set-Cookie` of
+// objects is typed instead, the properties are the names and values of the
+// cookies, which are set with the path `/` only.
+type ResponseHeaders struct {
+	Name   string
+	Fields []*ResponseHeader
+}
+
+type ResponseHeader struct {
+	Name     string
+	Type     string
+	Field    string
+	IsCookie bool
+	// IsCookieValues reports whether the cookies are typed by an object of
+	// their values.
+	IsCookieValues bool
+}
+
+func (p *Parser) warnf(format string, a ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, a...))
 }
 
 func NewParser() *Parser {
-	return &Parser{
-		Services: make(map[string]*ServiceInfo),
-		methods:  make(map[string]*ServiceMethod),
+	p := &Parser{
+		Services:       make(map[string]*ServiceInfo),
+		methods:        make(map[string]*ServiceMethod),
+		models:         make(map[string]struct{}),
+		paramGroups:    make(map[string]*ParamGroup),
+		paramTypes:     make(map[string]string),
+		goTypeMappings: make(map[string]string),
+		goPackages:     make(map[string]string),
 	}
+	for qualifier, path := range goBuiltinPackages {
+		p.goPackages[qualifier] = path
+	}
+	return p
 }
 
 func (p *Parser) Parse() error {
-	p.srcpath = filepath.Join(p.inpath, "go")
-	p.specpath = filepath.Join(p.inpath, "api", "openapi.yaml")
+	if p.typeMappingPath != "" {
+		if err := p.loadGoTypeMappings(p.typeMappingPath); err != nil {
+			return err
+		}
+	}
 
-	if err := p.parseGo(); err != nil {
-		return err
+	if p.hasGeneratedCode() {
+		p.srcpath = filepath.Join(p.inpath, "go")
+		p.specpath = filepath.Join(p.inpath, "api", "openapi.yaml")
+
+		if err := p.parseGo(); err != nil {
+			return err
+		}
 	}
 
 	if err := p.parseYaml(); err != nil {
 		return err
 	}
 
+	if err := p.checkMethods(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// hasGeneratedCode reports whether the input is the output of
+// openapi-generator-cli, or a single OpenAPI file.
+func (p *Parser) hasGeneratedCode() bool {
+	return p.inpath != ""
+}
+
 func (p *Parser) parseGo() error {
 	fset := gotoken.NewFileSet()
 	pkgs, err := goparser.ParseDir(fset, p.srcpath, nil, goparser.ParseComments)
@@ -145,10 +457,6 @@ func (p *Parser) parseFile(path string, file *goast.File) error {
 	if strings.HasPrefix(filename, "api_") {
 		return p.parseMethodNames(filename, file)
 	}
-	if strings.HasPrefix(filename, "model_") {
-		p.collectModel(path)
-		return nil
-	}
 
 	return nil
 }
@@ -181,6 +489,7 @@ func (p *Parser) parseMethodNames(path string, file *goast.File) error {
 		Name:     serviceName,
 		Var:      serviceName,
 		Methods:  make(map[string]*ServiceMethod),
+		packages: p.goPackages,
 	}
 
 	for name, obj := range file.Scope.Objects {
@@ -198,12 +507,8 @@ func (p *Parser) parseMethodNames(path string, file *goast.File) error {
 	return nil
 }
 
-func (p *Parser) collectModel(path string) {
-	p.modelPaths = append(p.modelPaths, path)
-}
-
 func (p *Parser) parseYaml() error {
-	swagger, err := oapi.NewSwaggerLoader().LoadSwaggerFromFile(p.specpath)
+	swagger, err := loadSwagger(p.specpath)
 	if err != nil {
 		return err
 	}
@@ -212,29 +517,53 @@ func (p *Parser) parseYaml() error {
 		return err
 	}
 
+	if !p.hasGeneratedCode() {
+		if err := p.parseServices(swagger.Paths); err != nil {
+			return err
+		}
+	}
+
 	if err := p.parseServiceComments(swagger.Tags); err != nil {
 		return err
 	}
 
-	if err := p.parseTypedefs(swagger.Components.Schemas); err != nil {
+	if err := p.parseModels(swagger.Components.Schemas); err != nil {
 		return err
 	}
 
-	for path, item := range swagger.Paths {
-		if err := p.parseOperation(item.Get, path, http.MethodGet); err != nil {
-			return err
-		}
-		if err := p.parseOperation(item.Post, path, http.MethodPost); err != nil {
-			return err
-		}
-		if err := p.parseOperation(item.Put, path, http.MethodPut); err != nil {
-			return err
-		}
-		if err := p.parseOperation(item.Delete, path, http.MethodDelete); err != nil {
-			return err
+	if err := p.parseSecuritySchemes(swagger.Components.SecuritySchemes); err != nil {
+		return err
+	}
+	p.security = swagger.Security
+
+	paths := make([]string, 0, len(swagger.Paths))
+	for path := range swagger.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := swagger.Paths[path]
+		for _, httpMethod := range oapiHttpMethods {
+			if err := p.parseOperation(item, path, httpMethod); err != nil {
+				return err
+			}
 		}
 	}
 
+	p.resolveEmbeds()
+	return nil
+}
+
+// checkMethods makes sure every method found in the generated code has been
+// bound to an operation in the specs, otherwise it has no route at all.
+func (p *Parser) checkMethods() error {
+	for name, method := range p.methods {
+		if method.HttpMethod == "" {
+			return fmt.Errorf("%w: method %q not found in operations",
+				ErrParserBadSpecs, name)
+		}
+	}
 	return nil
 }
 
@@ -264,6 +593,60 @@ func (p *Parser) parseRootURL(servers []*oapi.Server) error {
 	return nil
 }
 
+// parseServices collects services and methods from the operations, the same
+// way openapi-generator-cli does: an operation goes to the service of its first
+// tag, or the default service if it has no tags.
+func (p *Parser) parseServices(paths oapi.Paths) error {
+	for path, item := range paths {
+		for _, httpMethod := range oapiHttpMethods {
+			op := item.GetOperation(httpMethod)
+			if op == nil {
+				continue
+			}
+
+			if op.OperationID == "" {
+				return fmt.Errorf("%w: operation '%s %s' has no operationId",
+					ErrParserBadSpecs, httpMethod, path)
+			}
+
+			tag := "default"
+			if len(op.Tags) > 0 {
+				tag = op.Tags[0]
+			}
+
+			serviceName := OapiTagToServiceName(tag)
+			if _, ok := p.ignoredServices[serviceName]; ok {
+				continue
+			}
+
+			service, ok := p.Services[serviceName]
+			if !ok {
+				service = &ServiceInfo{
+					Filepath: OapiTagToServicePath(tag),
+					Name:     serviceName,
+					Var:      serviceName,
+					Methods:  make(map[string]*ServiceMethod),
+					packages: p.goPackages,
+				}
+				p.Services[serviceName] = service
+			}
+
+			name := strings.Title(op.OperationID)
+			if _, ok := p.methods[name]; ok {
+				return fmt.Errorf("%w: duplicate operation %q", ErrParserBadSpecs, name)
+			}
+
+			method := &ServiceMethod{
+				Name: name,
+			}
+			service.Methods[name] = method
+			p.methods[name] = method
+		}
+	}
+
+	return nil
+}
+
 func (p *Parser) parseServiceComments(tags oapi.Tags) error {
 	for _, tag := range tags {
 		serviceName := OapiTagToServiceName(tag.Name)
@@ -272,6 +655,10 @@ func (p *Parser) parseServiceComments(tags oapi.Tags) error {
 		}
 
 		service, ok := p.Services[serviceName]
+		if !ok && !p.hasGeneratedCode() {
+			// Tags without any operation have no services.
+			continue
+		}
 		if !ok {
 			return fmt.Errorf("%w: service %q not found in generated code",
 				ErrParserBadSpecs, serviceName)
@@ -281,25 +668,8 @@ func (p *Parser) parseServiceComments(tags oapi.Tags) error {
 	return nil
 }
 
-func (p *Parser) parseTypedefs(schemas oapi.Schemas) error {
-	for name, schema := range schemas {
-		if schema.Value.Type == "array" {
-			// Items in the array are required.
-			ty, err := OapiToGoType(schema, true)
-			if err != nil {
-				return err
-			}
-			p.Typedefs = append(p.Typedefs, Typedef{
-				Source: ty,
-				Target: name,
-			})
-		}
-	}
-
-	return nil
-}
-
-func (p *Parser) parseOperation(op *oapi.Operation, path, httpMethod string) error {
+func (p *Parser) parseOperation(item *oapi.PathItem, path, httpMethod string) error {
+	op := item.GetOperation(httpMethod)
 	if op == nil {
 		return nil
 	}
@@ -314,15 +684,31 @@ func (p *Parser) parseOperation(op *oapi.Operation, path, httpMethod string) err
 	method.Comment = op.Summary
 	method.Path = p.rootURL + OapiToGinPathParam(path)
 	method.HttpMethod = httpMethod
+	// Gin does not route HEAD requests to GET handlers by itself.
+	method.HasImplicitHead = httpMethod == http.MethodGet && item.Head == nil
 	method.HasGinCtx = p.isGinCtx
 
-	for _, param := range op.Parameters {
-		if err := p.parseParam(method, param.Value); err != nil {
+	for _, param := range mergeParams(item.Parameters, op.Parameters) {
+		if err := p.parseParam(method, param); err != nil {
 			return err
 		}
 	}
+	for _, q := range method.Queries {
+		if !q.isFormObject {
+			continue
+		}
+		for _, other := range method.Queries {
+			if other != q {
+				q.Others = append(q.Others, other.Name)
+			}
+		}
+	}
 
-	if err := p.parseBody(method, op.RequestBody); err != nil {
+	if err := p.parseSecurity(method, op); err != nil {
+		return err
+	}
+
+	if err := p.parseBody(method, op); err != nil {
 		return err
 	}
 
@@ -333,11 +719,51 @@ func (p *Parser) parseOperation(op *oapi.Operation, path, httpMethod string) err
 	return nil
 }
 
-func (p *Parser) parseParam(method *ServiceMethod, param *oapi.Parameter) error {
+// mergeParams merges the parameters of the path item and the operation, the
+// operation ones override those with the same name and location.
+func mergeParams(pathParams, opParams oapi.Parameters) oapi.Parameters {
+	var params oapi.Parameters
+	index := map[string]int{}
+	for _, ps := range []oapi.Parameters{pathParams, opParams} {
+		for _, param := range ps {
+			key := param.Value.In + ":" + param.Value.Name
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+func (p *Parser) parseParam(method *ServiceMethod, ref *oapi.ParameterRef) error {
 	m := method.Name
+	param := ref.Value
 	name := param.Name
 	schema := param.Schema
 
+	// Shared parameters are embedded as one struct for each component, or for
+	// each group of the `x-ginapi-group` extension, and their inline types are
+	// named after the component or the group.
+	var group string
+	typeName := m + OapiPropToGoField(name)
+	if ref.Ref != "" {
+		parts := strings.Split(ref.Ref, "/")
+		typeName = OapiPropToGoField(parts[len(parts)-1])
+		group = typeName + "Param"
+
+		var override string
+		if _, err := OapiExtension(param.ExtensionProps, extGinapiGroup, &override); err != nil {
+			return fmt.Errorf("%w: parameter '%s/%s': %v", ErrParserBadSpecs, m, name, err)
+		}
+		if override != "" {
+			group = strings.Title(override)
+			typeName = group + OapiPropToGoField(name)
+		}
+	}
+
 	if schema == nil {
 		// TODO: Only parses JSON schema now.
 		jsonSchema := param.Content.Get(mimeJSON)
@@ -347,103 +773,513 @@ func (p *Parser) parseParam(method *ServiceMethod, param *oapi.Parameter) error
 		schema = jsonSchema.Schema
 	}
 
-	ty, err := OapiToGoType(schema, param.Required)
+	ty, ok := p.paramTypes[ref.Ref]
+	if !ok || ref.Ref == "" {
+		var err error
+		ty, err = p.goType(schema, param.Required, typeName)
+		if err != nil {
+			return fmt.Errorf("%w: cannot get Go type from param '%s/%s': %v",
+				ErrParserBadParamSchema, m, name, err)
+		}
+		if ref.Ref != "" {
+			p.paramTypes[ref.Ref] = ty
+		}
+	}
+
+	isEnum := IsOapiEnum(schema.Value)
+	elem := strings.TrimPrefix(ty, "*")
+
+	// Arrays and objects are bound by the detail package, following the
+	// serialization style.
+	style, explode, err := oapiParamStyle(param)
+	if err != nil {
+		return fmt.Errorf("%w: parameter '%s/%s'", err, m, name)
+	}
+	userType, err := p.userGoType(schema.Value)
 	if err != nil {
 		return fmt.Errorf("%w: cannot get Go type from param '%s/%s': %v",
 			ErrParserBadParamSchema, m, name, err)
 	}
+	isComposite := userType == "" &&
+		(schema.Value.Type == "array" || schema.Value.Type == "object")
+
+	// The underlying type decides how the parameter is bound: by Gin for the
+	// builtin types, by the detail package for the formatted types, or by
+	// `encoding.TextUnmarshaler` for the user-defined types.
+	base := elem
+	if !isComposite {
+		base, err = p.oapiToGoType(&oapi.SchemaRef{Value: schema.Value}, true)
+		if err != nil {
+			return fmt.Errorf("%w: cannot get Go type from param '%s/%s': %v",
+				ErrParserBadParamSchema, m, name, err)
+		}
+	}
+	parser, isParsed := GoTypeToParser(base)
+	isText := !isParsed && !IsGoBuiltin(base) && IsGoQualified(base)
+
+	// Declaration of the field in the shared struct.
+	var field, tagKey, tag string
+
+	var convert string
+	if base != elem {
+		convert = elem
+	}
 
 	switch in := param.In; in {
 	case "path":
-		method.PathVars = append(method.PathVars, &PathVar{
+		pathVar := &PathVar{
 			Name:   name,
 			Type:   ty,
 			Field:  strings.Title(name),
-			Binder: "Param" + strings.Title(ty),
-		})
+			Binder: "Param" + strings.Title(base),
+			IsEnum: isEnum,
+			IsText: isText,
+		}
+		if isParsed {
+			pathVar.Binder = "Param" + parser
+		}
+		if !isText {
+			pathVar.Convert = convert
+		}
+		if isComposite || style != oapi.SerializationSimple {
+			pathVar.Style = style
+			pathVar.Explode = explode
+		}
+		if group != "" {
+			pathVar.Group = group
+			method.PathVarGroups = appendGroup(method.PathVarGroups, group)
+		}
+		field = pathVar.Field
+		method.PathVars = append(method.PathVars, pathVar)
 	case "query":
-		method.Queries = append(method.Queries, &Query{
-			Name:  name,
-			Type:  ty,
-			Field: strings.Title(name),
-		})
+		q := &Query{
+			Name:       name,
+			Type:       ty,
+			Elem:       elem,
+			Field:      strings.Title(name),
+			IsEnum:     isEnum,
+			IsOptional: !param.Required,
+			IsText:     isText,
+		}
+		if isParsed {
+			q.Parser = "Parse" + parser
+			q.Convert = convert
+		}
+		if isComposite {
+			q.Style = style
+			q.Explode = explode
+			q.isFormObject = schema.Value.Type == "object" &&
+				style == oapi.SerializationForm && explode
+		}
+		if group != "" {
+			q.Group = group
+			method.QueryGroups = appendGroup(method.QueryGroups, group)
+		}
+		field, tagKey, tag = q.Field, "form", q.Name
+		if isParsed || isText || isComposite {
+			tag = "-"
+		}
+		method.Queries = append(method.Queries, q)
 	case "header":
-		method.Headers = append(method.Headers, &Header{
-			Name:  name,
-			Type:  ty,
-			Field: strings.ReplaceAll(strings.Title(name), "-", ""),
-		})
+		h := &Header{
+			Name:       name,
+			Type:       ty,
+			Elem:       elem,
+			Field:      strings.ReplaceAll(strings.Title(name), "-", ""),
+			IsEnum:     isEnum,
+			IsOptional: !param.Required,
+			IsText:     isText,
+		}
+		if isParsed {
+			h.Parser = "Parse" + parser
+			h.Convert = convert
+		}
+		if isComposite {
+			h.Style = style
+			h.Explode = explode
+		}
+		if group != "" {
+			h.Group = group
+			method.HeaderGroups = appendGroup(method.HeaderGroups, group)
+		}
+		field, tagKey, tag = h.Field, "header", h.Name
+		if isParsed || isText || isComposite {
+			tag = "-"
+		}
+		method.Headers = append(method.Headers, h)
+	case "cookie":
+		if !isParsed && !isText && !IsGoBuiltin(base) {
+			return fmt.Errorf("%w: cookie '%s/%s' of type %s",
+				ErrParserBadParamSchema, m, name, base)
+		}
+		ck := &Cookie{
+			Name:       name,
+			Type:       ty,
+			Elem:       elem,
+			Field:      OapiPropToGoField(name),
+			IsEnum:     isEnum,
+			IsOptional: !param.Required,
+			IsText:     isText,
+			Parser:     "Parse" + strings.Title(base),
+			Convert:    convert,
+		}
+		if isParsed {
+			ck.Parser = "Parse" + parser
+		}
+		if group != "" {
+			ck.Group = group
+			method.CookieGroups = appendGroup(method.CookieGroups, group)
+		}
+		field, tagKey, tag = ck.Field, "cookie", ck.Name
+		method.Cookies = append(method.Cookies, ck)
 	default:
 		return fmt.Errorf("%w: %s", ErrParserBadParamKind, in)
 	}
 
+	if group == "" {
+		return nil
+	}
+	return p.addParamGroupField(group, ref.Ref, param, &Field{
+		Name:    name,
+		Type:    ty,
+		Field:   field,
+		Tag:     tag,
+		Comment: param.Description,
+	}, tagKey)
+}
+
+// oapiParamStyle returns the serialization style of the parameter, defaults
+// to form for queries and cookies, simple for path variables and headers.
+func oapiParamStyle(param *oapi.Parameter) (style string, explode bool, err error) {
+	var styles []string
+	switch param.In {
+	case oapi.ParameterInQuery:
+		styles = []string{
+			oapi.SerializationForm,
+			oapi.SerializationSpaceDelimited,
+			oapi.SerializationPipeDelimited,
+			oapi.SerializationDeepObject,
+		}
+	case oapi.ParameterInPath:
+		styles = []string{
+			oapi.SerializationSimple,
+			oapi.SerializationLabel,
+			oapi.SerializationMatrix,
+		}
+	case oapi.ParameterInHeader:
+		styles = []string{oapi.SerializationSimple}
+	default:
+		styles = []string{oapi.SerializationForm}
+	}
+
+	style = param.Style
+	if style == "" {
+		style = styles[0]
+	}
+	explode = style == oapi.SerializationForm
+	if param.Explode != nil {
+		explode = *param.Explode
+	}
+
+	for _, s := range styles {
+		if s == style {
+			return
+		}
+	}
+	err = fmt.Errorf("%w: %s in %s", ErrParserBadParamStyle, style, param.In)
+	return
+}
+
+// addParamGroupField adds the shared parameter to its group, once for each
+// component.
+func (p *Parser) addParamGroupField(group, ref string, param *oapi.Parameter, f *Field, tagKey string) error {
+	g, ok := p.paramGroups[group]
+	if !ok {
+		if err := p.addModel(group); err != nil {
+			return err
+		}
+		g = &ParamGroup{
+			Name:   group,
+			In:     param.In,
+			TagKey: tagKey,
+			refs:   make(map[string]struct{}),
+		}
+		p.paramGroups[group] = g
+		p.ParamGroups = append(p.ParamGroups, g)
+	}
+	if g.In != param.In {
+		return fmt.Errorf("%w: parameter group %q in both %s and %s",
+			ErrParserBadSpecs, group, g.In, param.In)
+	}
+
+	if _, ok := g.refs[ref]; ok {
+		return nil
+	}
+	g.refs[ref] = struct{}{}
+	g.Fields = append(g.Fields, f)
 	return nil
 }
 
-func (p *Parser) parseBody(method *ServiceMethod, body *oapi.RequestBodyRef) error {
+func appendGroup(groups []string, group string) []string {
+	for _, g := range groups {
+		if g == group {
+			return groups
+		}
+	}
+	return append(groups, group)
+}
+
+func (p *Parser) parseBody(method *ServiceMethod, op *oapi.Operation) error {
+	body := op.RequestBody
 	if body == nil {
 		return nil
 	}
-
-	// TODO: Only supports JSON and binary now.
-	if schema := body.Value.Content.Get(mimeJSON); schema != nil {
-		return p.parseJsonBody(method, schema.Schema.Ref)
-	} else if schema := body.Value.Content.Get(mimeOctetStream); schema != nil {
-		return p.parseBinaryBody(method)
+	content := body.Value.Content
+
+	// Media types other than multipart forms and binaries share the same
+	// model, and get decoded by the codecs.
+	if mediaTypes := oapiCodecMediaTypes(content); len(mediaTypes) > 0 {
+		return p.parseModelBody(method, content, mediaTypes)
+	} else if media := content.Get(mimeMultipartForm); media != nil {
+		return p.parseMultipartBody(method, media)
+	} else if len(content) > 0 {
+		return p.parseBinaryBody(method, op)
 	}
 
 	return fmt.Errorf("%w: request body of method %q", ErrParserNoSchema, method.Name)
 }
 
-func (p *Parser) parseJsonBody(method *ServiceMethod, ref string) error {
+func (p *Parser) parseModelBody(method *ServiceMethod, content oapi.Content, mediaTypes []string) error {
 	m := method.Name
 
-	if ref == "" {
-		return fmt.Errorf("%w: request body of method %q", ErrUtilUseRef, m)
+	schema := content[mediaTypes[0]].Schema
+	for _, t := range mediaTypes {
+		// URL-encoded forms are bound into the model by the `form` tags.
+		if v := schema.Value; t == mimeURLEncoded && !IsOapiStruct(v) && len(v.AllOf) == 0 {
+			return fmt.Errorf("%w: URL-encoded form of method %q is not an object",
+				ErrParserBadRequestSchema, m)
+		}
 	}
 
-	t, err := OapiRefToGoStruct(ref)
+	t, err := p.goType(schema, true, m+"RequestBody")
 	if err != nil {
 		return fmt.Errorf("%w: request body of method %q: %v", ErrParserBadRequestSchema, m, err)
 	}
-	if strings.HasPrefix(t, "Inline_object") {
-		return fmt.Errorf("%w: request body %q of method %q: %v",
-			ErrParserBadRequestSchema, t, m, ErrUtilUseRef)
-	}
 
 	method.RequestBody = t
+	method.RequestMediaTypes = mediaTypes
 	return nil
 }
 
-func (p *Parser) parseBinaryBody(method *ServiceMethod) error {
+func (p *Parser) parseBinaryBody(method *ServiceMethod, op *oapi.Operation) error {
+	// Streaming is enabled globally, or overridden by the operation.
+	isStream := p.isStreamBody
+	if _, err := OapiExtension(op.ExtensionProps, extGinapiStream, &isStream); err != nil {
+		return fmt.Errorf("%w: request body of method %q: %v", ErrParserBadRequestSchema, method.Name, err)
+	}
+
 	method.RequestBody = "[]byte"
+	if isStream {
+		method.RequestBody = "io.Reader"
+	}
+	method.MaxBodySize = p.maxBodySize
 	return nil
 }
 
+// oapiCodecMediaTypes returns the media types with non-binary schemas, besides
+// the multipart forms, JSON goes first.
+func oapiCodecMediaTypes(content oapi.Content) []string {
+	var ret []string
+	for t, media := range content {
+		if strings.HasPrefix(t, "multipart/") || media.Schema == nil || IsOapiBinary(media.Schema.Value) {
+			continue
+		}
+		ret = append(ret, t)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i] == mimeJSON || ret[j] == mimeJSON {
+			return ret[i] == mimeJSON
+		}
+		return ret[i] < ret[j]
+	})
+	return ret
+}
+
 func (p *Parser) parseResponses(method *ServiceMethod, resps oapi.Responses) error {
 	m := method.Name
 
-	// TODO: Only 200 response is supported now, maybe we can use an interface
-	// for all possible response schemas on 200, 400, etc. But hey, we don't
-	// have sealed classes in Go :(
-	resp := resps.Get(200)
-	if resp == nil {
+	// A single 200 response keeps the plain signature, the schema is returned
+	// directly and the status is always 200.
+	if len(resps) == 0 {
 		// It's okay for this method to return a single error, without schemas.
 		return nil
 	}
+	if ok, err := p.parseEventStream(method, resps); ok || err != nil {
+		return err
+	}
+	if resp := resps.Get(http.StatusOK); resp != nil && len(resps) == 1 {
+		t, mediaTypes, err := p.parseResponseBody(method, resp.Value, m+"Response")
+		if err != nil {
+			return err
+		}
+		method.Response = t
+		method.ResponseMediaTypes = mediaTypes
+
+		h, err := p.parseResponseHeaders(method, resp.Value, m+"Response")
+		if err != nil {
+			return err
+		}
+		method.ResponseHeaders = h
+		return nil
+	}
+
+	statuses := make([]string, 0, len(resps))
+	for status := range resps {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	iface := m + "Response"
+	for _, status := range statuses {
+		resp := resps[status].Value
+
+		r := &Response{Interface: iface}
+		if resp.Description != nil {
+			r.Comment = *resp.Description
+		}
+
+		switch {
+		case status == "default":
+			r.Name = m + "Default"
+		case len(status) == 3 && strings.HasSuffix(strings.ToUpper(status), "XX"):
+			// Status ranges like 4XX, the exact code is given by the user.
+			r.Name = m + strings.ToUpper(status)
+		default:
+			code, err := strconv.Atoi(status)
+			if err != nil || code < 100 || code > 599 {
+				return fmt.Errorf("%w: %q of method %q", ErrParserBadStatus, status, m)
+			}
+			r.Name = m + status
+			r.StatusCode = code
+		}
+		r.Type = strings.ToLower(r.Name[:1]) + r.Name[1:] + "Response"
+
+		t, mediaTypes, err := p.parseResponseBody(method, resp, r.Name+"Response")
+		if err != nil {
+			return err
+		}
+		r.Body = t
+		r.MediaTypes = mediaTypes
+
+		h, err := p.parseResponseHeaders(method, resp, r.Name+"Response")
+		if err != nil {
+			return err
+		}
+		r.Headers = h
+
+		method.Responses = append(method.Responses, r)
+	}
 
-	// TODO: Only supports JSON now.
-	jsonSchema := resp.Value.Content.Get(mimeJSON)
-	if jsonSchema == nil {
-		return fmt.Errorf("%w: response of method %q", ErrParserNoSchema, m)
+	return nil
+}
+
+func (p *Parser) parseResponseBody(method *ServiceMethod, resp *oapi.Response, name string) (string, []string, error) {
+	m := method.Name
+
+	if len(resp.Content) == 0 {
+		// Responses without content only write the status.
+		return "", nil, nil
+	}
+
+	mediaTypes := oapiCodecMediaTypes(resp.Content)
+	if len(mediaTypes) == 0 {
+		// Binary bodies are streamed.
+		for t := range resp.Content {
+			mediaTypes = append(mediaTypes, t)
+		}
+		sort.Strings(mediaTypes)
+		return goStreamType, mediaTypes, nil
 	}
 
 	// Types for responses could be pointers.
-	t, err := OapiToGoType(jsonSchema.Schema, false)
+	t, err := p.goType(resp.Content[mediaTypes[0]].Schema, false, name)
 	if err != nil {
-		return fmt.Errorf("%w: response schema of method %q: %v", ErrParserBadRequestSchema, m, err)
+		return "", nil, fmt.Errorf("%w: response schema of method %q: %v", ErrParserBadRequestSchema, m, err)
 	}
 
-	method.Response = t
+	return t, mediaTypes, nil
+}
+
+func (p *Parser) parseResponseHeaders(method *ServiceMethod, resp *oapi.Response, name string) (*ResponseHeaders, error) {
+	m := method.Name
+
+	names := make([]string, 0, len(resp.Headers))
+	for header := range resp.Headers {
+		// Content types are negotiated instead.
+		if !strings.EqualFold(header, "Content-Type") {
+			names = append(names, header)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	headers := &ResponseHeaders{Name: name + "Headers"}
+	for _, header := range names {
+		h := &ResponseHeader{
+			Name:  header,
+			Field: OapiPropToGoField(header),
+		}
+
+		if strings.EqualFold(header, "Set-Cookie") {
+			if err := p.parseSetCookie(h, resp.Headers[header].Value, headers.Name); err != nil {
+				return nil, fmt.Errorf("%w: response header '%s/%s': %v",
+					ErrParserBadParamSchema, m, header, err)
+			}
+		} else {
+			value := resp.Headers[header].Value
+			if value.Schema == nil {
+				return nil, fmt.Errorf("%w: response header '%s/%s'", ErrParserNoSchema, m, header)
+			}
+			t, err := p.goType(value.Schema, value.Required, headers.Name+h.Field)
+			if err != nil {
+				return nil, fmt.Errorf("%w: response header '%s/%s': %v",
+					ErrParserBadParamSchema, m, header, err)
+			}
+			h.Type = t
+		}
+
+		headers.Fields = append(headers.Fields, h)
+	}
+	return headers, nil
+}
+
+// parseSetCookie types the cookies of the `Set-Cookie` header, either as
+// `[]*http.Cookie` for strings, or as an object of their values.
+func (p *Parser) parseSetCookie(h *ResponseHeader, header *oapi.Header, headersName string) error {
+	var schema *oapi.Schema
+	if header.Schema != nil {
+		schema = header.Schema.Value
+		if schema.Type == "array" && schema.Items != nil {
+			schema = schema.Items.Value
+		}
+	}
+
+	switch {
+	case schema == nil || schema.Type == "string":
+		h.Type = "[]*http.Cookie"
+		h.IsCookie = true
+	case IsOapiStruct(schema) || schema.Type == "object" && schema.AdditionalProperties != nil:
+		t, err := p.goType(header.Schema, header.Required, headersName+h.Field)
+		if err != nil {
+			return err
+		}
+		h.Type = t
+		h.IsCookieValues = true
+	default:
+		return fmt.Errorf("cookies of type %q cannot be typed, "+
+			"use strings for http.Cookie, or objects of the cookie values", schema.Type)
+	}
 	return nil
 }
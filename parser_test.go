@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  error
+	}{
+		{"enum_bool_string.yaml", ErrParserBadSpecs},
+		{"enum_float_integer.yaml", ErrParserBadSpecs},
+		{"enum_string_boolean.yaml", ErrParserBadSpecs},
+		{"set_cookie_integer.yaml", ErrParserBadParamSchema},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			p := NewParser()
+			p.specpath = filepath.Join("testdata", "errors", tt.spec)
+			if err := p.Parse(); !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	oapi "github.com/getkin/kin-openapi/openapi3"
+)
+
+const (
+	securityBearer = "bearer"
+	securityBasic  = "basic"
+	securityAPIKey = "apiKey"
+	securityOAuth2 = "oauth2"
+)
+
+// SecurityScheme is authenticated by one method of the generated
+// `SecurityHandler`.
+type SecurityScheme struct {
+	Name    string
+	Comment string
+	Method  string
+	Kind    string
+
+	// Location and name of the API keys.
+	In    string
+	Param string
+}
+
+// SecurityRequirement is met when all of its schemes pass, operations pass when
+// any of their requirements is met.
+type SecurityRequirement struct {
+	Schemes []*RequiredScheme
+}
+
+type RequiredScheme struct {
+	Name   string
+	Scopes []string
+}
+
+func (p *Parser) parseSecuritySchemes(schemes map[string]*oapi.SecuritySchemeRef) error {
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		v := schemes[name].Value
+		s := &SecurityScheme{
+			Name:    name,
+			Comment: v.Description,
+			Method:  "Handle" + OapiPropToGoField(name),
+		}
+
+		switch v.Type {
+		case "http":
+			switch strings.ToLower(v.Scheme) {
+			case securityBearer:
+				s.Kind = securityBearer
+			case securityBasic:
+				s.Kind = securityBasic
+			default:
+				return fmt.Errorf("%w: HTTP scheme %q of security scheme %q",
+					ErrParserBadSecurity, v.Scheme, name)
+			}
+		case securityAPIKey:
+			switch v.In {
+			case oapi.ParameterInHeader, oapi.ParameterInQuery, oapi.ParameterInCookie:
+			default:
+				return fmt.Errorf("%w: API key in %q of security scheme %q",
+					ErrParserBadSecurity, v.In, name)
+			}
+			s.Kind = securityAPIKey
+			s.In = v.In
+			s.Param = v.Name
+		case securityOAuth2, "openIdConnect":
+			// Both are bearer tokens with scopes to the resource server.
+			s.Kind = securityOAuth2
+		default:
+			return fmt.Errorf("%w: type %q of security scheme %q",
+				ErrParserBadSecurity, v.Type, name)
+		}
+
+		p.SecuritySchemes = append(p.SecuritySchemes, s)
+	}
+	return nil
+}
+
+// parseSecurity parses the requirements of the operation, or the global ones if
+// the operation has none, an empty list means no security at all.
+func (p *Parser) parseSecurity(method *ServiceMethod, op *oapi.Operation) error {
+	reqs := p.security
+	if op.Security != nil {
+		reqs = *op.Security
+	}
+
+	schemes := map[string]struct{}{}
+	for _, s := range p.SecuritySchemes {
+		schemes[s.Name] = struct{}{}
+	}
+
+	for _, req := range reqs {
+		names := make([]string, 0, len(req))
+		for name := range req {
+			if _, ok := schemes[name]; !ok {
+				return fmt.Errorf("%w: unknown security scheme %q of method %q",
+					ErrParserBadSecurity, name, method.Name)
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		r := &SecurityRequirement{}
+		for _, name := range names {
+			r.Schemes = append(r.Schemes, &RequiredScheme{Name: name, Scopes: req[name]})
+		}
+		method.Security = append(method.Security, r)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package additional_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/anqur/ginapi/testdata/additional/ginapi"
+)
+
+func TestEmbeddedAdditional(t *testing.T) {
+	const data = `{"a":"x","b":1,"c":"z","d":true}`
+
+	var m ginapi.Deep
+	if err := json.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.A == nil || *m.A != "x" || m.B == nil || *m.B != 1 || m.D == nil || !*m.D {
+		t.Fatalf("bad properties: %+v", m)
+	}
+	if want := map[string]string{"c": "z"}; !reflect.DeepEqual(m.AdditionalProperties, want) {
+		t.Fatalf("got additional properties %v, want %v", m.AdditionalProperties, want)
+	}
+
+	out, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != data {
+		t.Fatalf("got %s, want %s", out, data)
+	}
+}
+
+func TestEmbeddedAdditionalBadProperty(t *testing.T) {
+	var m ginapi.Ext
+	err := json.Unmarshal([]byte(`{"a":"x","b":"y"}`), &m)
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field != "b" {
+		t.Fatalf("got %v, want a type error of b", err)
+	}
+}
@@ -0,0 +1,58 @@
+package headers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anqur/ginapi/testdata/headers/ginapi"
+	"github.com/gin-gonic/gin"
+)
+
+type service struct{}
+
+func (service) GetA(*gin.Context) (*string, ginapi.GetAResponseHeaders, error) {
+	body := "a"
+	return &body, ginapi.GetAResponseHeaders{
+		XRateLimitRemaining: 10,
+		SetCookie:           []*http.Cookie{{Name: "theme", Value: "dark", HttpOnly: true}},
+	}, nil
+}
+
+func (service) GetC(*gin.Context) (ginapi.GetCResponse, error) {
+	visits := int64(3)
+	return ginapi.GetC204(ginapi.GetC204ResponseHeaders{
+		SetCookie: ginapi.GetC204ResponseHeadersSetCookie{Session: "s1", Visits: &visits},
+	}), nil
+}
+
+func (service) PostB(*gin.Context) (ginapi.PostBResponse, error) {
+	return ginapi.PostB400(), nil
+}
+
+func TestSetCookie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginapi.RegisterDefaultService(service{})
+	r := ginapi.Initialize(gin.New())
+
+	tests := []struct {
+		path    string
+		cookies []string
+	}{
+		{"/v1/a", []string{"theme=dark; HttpOnly"}},
+		{"/v1/c", []string{"session=s1; Path=/", "visits=3; Path=/"}},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		got := w.Header()["Set-Cookie"]
+		if len(got) != len(tt.cookies) {
+			t.Fatalf("%s: got cookies %q, want %q", tt.path, got, tt.cookies)
+		}
+		for i := range got {
+			if got[i] != tt.cookies[i] {
+				t.Fatalf("%s: got cookies %q, want %q", tt.path, got, tt.cookies)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package methods_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/anqur/ginapi/testdata/methods/ginapi"
+	"github.com/gin-gonic/gin"
+)
+
+type service struct{}
+
+func (service) GetItem(c *gin.Context, vars ginapi.GetItemPathVars) (ginapi.GetItemResponse, error) {
+	if vars.Id != "1" {
+		return ginapi.GetItem404(), nil
+	}
+	return ginapi.GetItem200(&ginapi.Item{Name: "one"}), nil
+}
+
+func (service) ItemOptions(*gin.Context, ginapi.ItemOptionsPathVars) (ginapi.ItemOptionsResponse, error) {
+	return ginapi.ItemOptions204(), nil
+}
+
+func (service) PatchItem(*gin.Context, ginapi.PatchItemPathVars, ginapi.Item) (ginapi.PatchItemResponse, error) {
+	return ginapi.PatchItem204(), nil
+}
+
+func (service) TraceItem(*gin.Context, ginapi.TraceItemPathVars) error {
+	return nil
+}
+
+func TestMethods(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginapi.RegisterDefaultService(service{})
+	r := ginapi.Initialize(gin.New())
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/v1/items/1", "", http.StatusOK},
+		{http.MethodGet, "/v1/items/2", "", http.StatusNotFound},
+		{http.MethodHead, "/v1/items/1", "", http.StatusOK},
+		{http.MethodPatch, "/v1/items/1", `{"name":"two"}`, http.StatusNoContent},
+		{http.MethodOptions, "/v1/items/1", "", http.StatusNoContent},
+		{http.MethodTrace, "/v1/items/1", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		if tt.body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.status)
+		}
+	}
+}
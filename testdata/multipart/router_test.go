@@ -0,0 +1,105 @@
+package multipart_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/anqur/ginapi/testdata/multipart/ginapi"
+	"github.com/gin-gonic/gin"
+)
+
+type service struct {
+	req ginapi.UploadForm
+	raw []byte
+}
+
+func (s *service) Upload(c *gin.Context, req ginapi.UploadForm) error {
+	s.req = req
+	if req.Raw != nil {
+		raw, err := ioutil.ReadAll(req.Raw)
+		if err != nil {
+			return err
+		}
+		s.raw = raw
+	}
+	return nil
+}
+
+func newForm(t *testing.T, title bool, avatar bool) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	if title {
+		if err := w.WriteField("title", "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if avatar {
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", `form-data; name="avatar"; filename="a.png"`)
+		h.Set("Content-Type", "image/png")
+		part, err := w.CreatePart(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte("png")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	part, err := w.CreateFormFile("raw", "raw.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte("raw")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &body, w.FormDataContentType()
+}
+
+func TestUpload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		title, avatar bool
+		status        int
+	}{
+		{"complete", true, true, http.StatusOK},
+		{"missing title", false, true, http.StatusBadRequest},
+		{"missing avatar", true, false, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{}
+			ginapi.RegisterDefaultService(s)
+			r := ginapi.Initialize(gin.New())
+
+			body, contentType := newForm(t, tt.title, tt.avatar)
+			req := httptest.NewRequest(http.MethodPost, "/v1/upload", body)
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("got %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if s.req.Title != "hello" || s.req.Avatar == nil || s.req.Avatar.Filename != "a.png" {
+				t.Fatalf("bad form: %+v", s.req)
+			}
+			if string(s.raw) != "raw" {
+				t.Fatalf("got raw %q, want %q", s.raw, "raw")
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package styles_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anqur/ginapi/testdata/styles/ginapi"
+	"github.com/gin-gonic/gin"
+)
+
+type service struct {
+	list  ginapi.ListItemsQueries
+	patch ginapi.PatchItemsQueries
+	req   ginapi.PatchItemsRequestBody
+}
+
+func (s *service) ListItems(c *gin.Context, q ginapi.ListItemsQueries) error {
+	s.list = q
+	return nil
+}
+
+func (s *service) PatchItems(c *gin.Context, q ginapi.PatchItemsQueries, req ginapi.PatchItemsRequestBody) (ginapi.PatchItemsResponse, error) {
+	s.patch, s.req = q, req
+	return ginapi.PatchItems204(), nil
+}
+
+func TestStyles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &service{}
+	ginapi.RegisterDefaultService(s)
+	r := ginapi.Initialize(gin.New())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/items?limit=2&ids=1,2&color=red", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusOK)
+	}
+	if s.list.Limit == nil || *s.list.Limit != 2 {
+		t.Fatalf("got limit %v, want 2", s.list.Limit)
+	}
+	if s.list.Ids == nil || !reflect.DeepEqual(*s.list.Ids, []int64{1, 2}) {
+		t.Fatalf("got ids %v, want [1 2]", s.list.Ids)
+	}
+	if want := map[string]string{"color": "red"}; s.list.Filter == nil || !reflect.DeepEqual(*s.list.Filter, want) {
+		t.Fatalf("got filter %v, want %v", s.list.Filter, want)
+	}
+}
+
+func TestQueriesWithBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &service{}
+	ginapi.RegisterDefaultService(s)
+	r := ginapi.Initialize(gin.New())
+
+	req := httptest.NewRequest(http.MethodPatch, "/v1/items?tenant=x", strings.NewReader(`{"name":"y"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if s.patch.Tenant != "x" {
+		t.Fatalf("got tenant %q, want %q", s.patch.Tenant, "x")
+	}
+	if s.req.Name == nil || *s.req.Name != "y" {
+		t.Fatalf("got name %v, want %q", s.req.Name, "y")
+	}
+}
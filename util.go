@@ -1,17 +1,46 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
+// goBuiltinPackages are the import paths of the packages used by the generated
+// types, the packages of the user-defined types are added by the parser.
+var goBuiltinPackages = map[string]string{
+	"time":      "time",
+	"io":        "io",
+	"multipart": "mime/multipart",
+	"http":      "net/http",
+	"uuid":      "github.com/google/uuid",
+	"detail":    "github.com/anqur/ginapi/utils/detail",
+}
+
+// goTypeParsers are the suffixes of the parsing functions in the detail package
+// for the formatted types, e.g. `ParamUUID` and `ParseUUID`.
+var goTypeParsers = map[string]string{
+	"time.Time":   "Time",
+	"detail.Date": "Date",
+	"uuid.UUID":   "UUID",
+	"[]byte":      "Bytes",
+	"detail.URL":  "URL",
+}
+
+var goQualifierRegexp = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.`)
+
 var (
 	ErrUtilUseRef            = errors.New("inline objects not recommended, use $ref instead")
 	ErrUtilBadOapiSchemaType = errors.New("bad OAPI schema type")
 	ErrUtilBadOapiRef        = errors.New("bad OAPI ref")
+	ErrUtilBadOapiExtension  = errors.New("bad OAPI extension")
 )
 
 func OapiTagToServiceName(tag string) string {
@@ -23,6 +52,20 @@ func OapiTagToServiceName(tag string) string {
 	return strings.Join(parts, "")
 }
 
+func OapiTagToServicePath(tag string) string {
+	return "api_" + strings.ReplaceAll(strings.ToLower(tag), " ", "_") + ".go"
+}
+
+func OapiPropToGoField(prop string) string {
+	parts := strings.FieldsFunc(prop, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	})
+	for i := 0; i < len(parts); i++ {
+		parts[i] = strings.Title(parts[i])
+	}
+	return strings.Join(parts, "")
+}
+
 func OapiRefToGoStruct(ref string) (string, error) {
 	parts := strings.Split(ref, "/")
 	if l := len(parts); l > 0 {
@@ -31,7 +74,13 @@ func OapiRefToGoStruct(ref string) (string, error) {
 	return "", fmt.Errorf("%w: %s", ErrUtilBadOapiRef, ref)
 }
 
-func OapiToGoType(ref *openapi3.SchemaRef, required bool) (ret string, err error) {
+func (p *Parser) oapiToGoType(ref *openapi3.SchemaRef, required bool) (ret string, err error) {
+	if ref.Ref == "" && ref.Value.Nullable {
+		// Nullable values are optional as well, e.g. `type: [string, "null"]`
+		// of OpenAPI 3.1.
+		required = false
+	}
+
 	if ref.Ref != "" {
 		var t string
 		t, err = OapiRefToGoStruct(ref.Ref)
@@ -39,7 +88,9 @@ func OapiToGoType(ref *openapi3.SchemaRef, required bool) (ret string, err error
 			return
 		}
 		ret = t
-	} else {
+	} else if ret, err = p.userGoType(ref.Value); err != nil {
+		return
+	} else if ret == "" {
 		schema := ref.Value
 		t := schema.Type
 
@@ -48,32 +99,59 @@ func OapiToGoType(ref *openapi3.SchemaRef, required bool) (ret string, err error
 			switch schema.Format {
 			case "float":
 				ret = "float32"
-			case "double":
+			default:
 				ret = "float64"
 			}
 		case "integer":
 			switch f := schema.Format; f {
 			case "int32":
-				fallthrough
-			case "int64":
 				ret = f
+			default:
+				ret = "int64"
 			}
 		case "string":
-			ret = t
+			switch schema.Format {
+			case "date-time":
+				ret = "time.Time"
+			case "date":
+				ret = "detail.Date"
+			case "uuid":
+				ret = "uuid.UUID"
+			case "byte", "binary":
+				// Binary bodies are streamed, binary properties of the models
+				// are only bytes.
+				ret = "[]byte"
+			case "uri":
+				ret = "detail.URL"
+			default:
+				ret = t
+			}
 		case "boolean":
 			ret = "bool"
 		case "array":
 			var tt string
-			tt, err = OapiToGoType(schema.Items, required)
+			// Items in the array are required.
+			tt, err = p.oapiToGoType(schema.Items, true)
 			if err != nil {
 				return
 			}
 			ret = fmt.Sprintf("[]%s", tt)
 		case "object":
-			if schema.Properties != nil {
+			if IsOapiStruct(schema) {
 				err = ErrUtilUseRef
 				return
 			}
+			if items := schema.AdditionalProperties; items != nil {
+				var tt string
+				tt, err = p.oapiToGoType(items, true)
+				if err != nil {
+					return
+				}
+				ret = fmt.Sprintf("map[string]%s", tt)
+			} else {
+				// Free-form objects.
+				ret = "map[string]interface{}"
+			}
 		default:
 			err = fmt.Errorf("%w: %s", ErrUtilBadOapiSchemaType, schema.Type)
 			return
@@ -87,6 +165,228 @@ func OapiToGoType(ref *openapi3.SchemaRef, required bool) (ret string, err error
 	return
 }
 
+// OapiVariantToGoField names the field of a union variant after its type,
+// returns empty if the type is not a plain name, e.g. slices and maps.
+func OapiVariantToGoField(ty string) string {
+	for _, r := range ty {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return ""
+		}
+	}
+	return strings.Title(ty)
+}
+
+// OapiEnumToGoConst turns an enum value into the suffix of its constant name.
+func OapiEnumToGoConst(value interface{}) string {
+	if x, ok := value.(float64); ok {
+		return strings.NewReplacer("-", "Minus", ".", "_").
+			Replace(strconv.FormatFloat(x, 'f', -1, 64))
+	}
+
+	parts := strings.FieldsFunc(OapiPropToGoField(fmt.Sprint(value)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if ret := strings.Join(parts, ""); ret != "" {
+		return ret
+	}
+	return "Empty"
+}
+
+// OapiExtension decodes the extension value into v, returns false if the
+// extension is not specified.
+func OapiExtension(props openapi3.ExtensionProps, name string, v interface{}) (bool, error) {
+	ext, ok := props.Extensions[name]
+	if !ok {
+		return false, nil
+	}
+
+	raw, ok := ext.(json.RawMessage)
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrUtilBadOapiExtension, name)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("%w: %s: %v", ErrUtilBadOapiExtension, name, err)
+	}
+	return true, nil
+}
+
+// IsOapiEnum reports whether the schema, or the items of the array schema, has
+// enum values.
+func IsOapiEnum(schema *openapi3.Schema) bool {
+	if schema.Type == "array" && schema.Items != nil {
+		return IsOapiEnum(schema.Items.Value)
+	}
+	// Null is only there for nullable enums.
+	for _, v := range schema.Enum {
+		if v != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOapiStruct reports whether the schema is an object with properties, or
+// explicitly without additional properties, which is generated as a struct.
+// Other objects are maps.
+func IsOapiStruct(schema *openapi3.Schema) bool {
+	if schema.Type != "object" {
+		return false
+	}
+	if len(schema.Properties) > 0 {
+		return true
+	}
+	allowed := schema.AdditionalPropertiesAllowed
+	return allowed != nil && !*allowed && schema.AdditionalProperties == nil
+}
+
+// HasOapiAdditionalProperties reports whether the object schema allows
+// additional properties explicitly.
+func HasOapiAdditionalProperties(schema *openapi3.Schema) bool {
+	if schema.AdditionalProperties != nil {
+		return true
+	}
+	allowed := schema.AdditionalPropertiesAllowed
+	return allowed != nil && *allowed
+}
+
+// GoTypeToParser returns the suffix of the parsing functions for the type,
+// false if the type is bound by Gin itself.
+func GoTypeToParser(ty string) (string, bool) {
+	p, ok := goTypeParsers[strings.TrimPrefix(ty, "*")]
+	return p, ok
+}
+
+// OapiXML is the XML object of the schema.
+type OapiXML struct {
+	Name      string `json:"name"`
+	Attribute bool   `json:"attribute"`
+	Wrapped   bool   `json:"wrapped"`
+}
+
+// GetOapiXML returns the XML object of the schema, or the zero value if not
+// specified.
+func GetOapiXML(schema *openapi3.Schema) (x OapiXML, err error) {
+	if schema.XML == nil {
+		return
+	}
+	data, err := json.Marshal(schema.XML)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &x); err != nil {
+		err = fmt.Errorf("%w: xml: %v", ErrUtilBadOapiSchemaType, err)
+	}
+	return
+}
+
+// IsOapiBinary reports whether the schema is a `binary` string, i.e. a file.
+func IsOapiBinary(schema *openapi3.Schema) bool {
+	return schema.Type == "string" && schema.Format == "binary"
+}
+
+// IsGoBuiltin reports whether the type is a builtin scalar type.
+func IsGoBuiltin(ty string) bool {
+	switch ty {
+	case "string", "bool",
+		"int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64":
+		return true
+	}
+	return false
+}
+
+// IsGoQualified reports whether the type is a qualified identifier, e.g.
+// `decimal.Decimal`.
+func IsGoQualified(ty string) bool {
+	return goQualifierRegexp.MatchString(ty) && !strings.ContainsAny(ty, "[]*")
+}
+
+// Imports is a set of import declarations of the generated code, by the import
+// paths and the qualifiers referring to them.
+type Imports struct {
+	packages map[string]string
+	paths    map[string]map[string]struct{}
+}
+
+// NewImports returns the imports of the paths, the qualified types are looked
+// up in the packages.
+func NewImports(packages map[string]string, paths ...string) *Imports {
+	i := &Imports{
+		packages: packages,
+		paths:    map[string]map[string]struct{}{},
+	}
+	for _, path := range paths {
+		i.Add(path)
+	}
+	return i
+}
+
+// Add adds the import path, referred to by its package name.
+func (i *Imports) Add(path string) {
+	i.add(path, "")
+}
+
+// AddType adds the packages of the qualified identifiers in the type.
+func (i *Imports) AddType(ty string) {
+	for _, m := range goQualifierRegexp.FindAllStringSubmatch(ty, -1) {
+		if path, ok := i.packages[m[1]]; ok {
+			i.add(path, m[1])
+		}
+	}
+}
+
+func (i *Imports) add(path, qualifier string) {
+	qualifiers, ok := i.paths[path]
+	if !ok {
+		qualifiers = map[string]struct{}{}
+		i.paths[path] = qualifiers
+	}
+	qualifiers[qualifier] = struct{}{}
+}
+
+// Std returns the import declarations of the standard packages.
+func (i *Imports) Std() []string {
+	return i.filter(true)
+}
+
+// Others returns the import declarations of the packages besides the standard
+// ones.
+func (i *Imports) Others() []string {
+	return i.filter(false)
+}
+
+func (i *Imports) filter(std bool) []string {
+	var paths []string
+	for path := range i.paths {
+		first := strings.SplitN(path, "/", 2)[0]
+		if isStd := !strings.Contains(first, "."); isStd == std {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var ret []string
+	for _, path := range paths {
+		// The same package could be imported by multiple names.
+		qualifiers := make([]string, 0, len(i.paths[path]))
+		for q := range i.paths[path] {
+			qualifiers = append(qualifiers, q)
+		}
+		sort.Strings(qualifiers)
+
+		seen := map[string]struct{}{}
+		for _, q := range qualifiers {
+			spec := goImportSpec(path, q)
+			if _, ok := seen[spec]; !ok {
+				seen[spec] = struct{}{}
+				ret = append(ret, spec)
+			}
+		}
+	}
+	return ret
+}
+
 func OapiToGinPathParam(param string) (ret string) {
 	ret = param
 	ret = strings.ReplaceAll(ret, "{", ":")
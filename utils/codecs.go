@@ -0,0 +1,17 @@
+package ginapiutil
+
+import "github.com/anqur/ginapi/utils/detail"
+
+// Codec decodes request bodies and encodes response bodies of a media type,
+// the generated handlers negotiate the media types with the registered codecs.
+type Codec = detail.Codec
+
+// RegisterCodec plugs in the codec of a media type, e.g. `text/csv`, or
+// replaces the builtin ones of JSON, XML, plain text and URL-encoded forms.
+func RegisterCodec(mediaType string, codec Codec) {
+	detail.RegisterCodec(mediaType, codec)
+}
+
+// Stream is a streaming response body of binary media types, with the optional
+// content length, type and filename.
+type Stream = detail.Stream
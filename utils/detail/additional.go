@@ -0,0 +1,46 @@
+package detail
+
+import "encoding/json"
+
+// MarshalAdditional encodes the model along with its additional properties,
+// the declared properties take precedence.
+func MarshalAdditional(model interface{}, additional interface{}) ([]byte, error) {
+	props := map[string]json.RawMessage{}
+	if err := remarshal(additional, &props); err != nil {
+		return nil, err
+	}
+
+	declared := map[string]json.RawMessage{}
+	if err := remarshal(model, &declared); err != nil {
+		return nil, err
+	}
+	for k, v := range declared {
+		props[k] = v
+	}
+
+	return json.Marshal(props)
+}
+
+// UnmarshalAdditional decodes the properties not declared into additional,
+// which is a pointer to a map.
+func UnmarshalAdditional(data []byte, additional interface{}, declared ...string) error {
+	props := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &props); err != nil {
+		return err
+	}
+	for _, k := range declared {
+		delete(props, k)
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	return remarshal(props, additional)
+}
+
+func remarshal(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
@@ -0,0 +1,15 @@
+package detail
+
+import "encoding/json"
+
+// MarshalAllOf encodes the values as JSON objects merged into one, the latter
+// values take precedence.
+func MarshalAllOf(values ...interface{}) ([]byte, error) {
+	props := map[string]json.RawMessage{}
+	for _, v := range values {
+		if err := remarshal(v, &props); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(props)
+}
@@ -0,0 +1,251 @@
+package detail
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrNotAcceptable = errors.New("not acceptable")
+	ErrNoCodec       = errors.New("no codec registered")
+)
+
+// Codec decodes request bodies and encodes response bodies of a media type.
+type Codec interface {
+	Decode(r io.Reader, v interface{}) error
+	Encode(w io.Writer, v interface{}) error
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		"application/json":                  JSONCodec{},
+		"application/xml":                   XMLCodec{},
+		"text/xml":                          XMLCodec{},
+		"text/plain":                        TextCodec{},
+		"application/x-www-form-urlencoded": FormCodec{},
+	}
+)
+
+// RegisterCodec registers the codec of the media type, the existing one is
+// replaced.
+func RegisterCodec(mediaType string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[strings.ToLower(mediaType)] = codec
+}
+
+// LookupCodec returns the codec of the media type, types with the `+json` or
+// `+xml` suffix fall back to the JSON or XML codecs, e.g.
+// `application/problem+json`.
+func LookupCodec(mediaType string) (Codec, bool) {
+	t, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return nil, false
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	if codec, ok := codecs[t]; ok {
+		return codec, true
+	}
+	switch {
+	case strings.HasSuffix(t, "+json"):
+		return codecs["application/json"], true
+	case strings.HasSuffix(t, "+xml"):
+		return codecs["application/xml"], true
+	}
+	return nil, false
+}
+
+// ContentType returns the media type of the request body, which should be one
+// of the media types, or the first one if the request has no content type.
+func ContentType(c *gin.Context, mediaTypes ...string) (string, error) {
+	t := c.ContentType()
+	if t == "" {
+		return mediaTypes[0], nil
+	}
+	if !MatchMediaType(t, mediaTypes...) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedMediaType, t)
+	}
+	return t, nil
+}
+
+// DecodeBody decodes the request body of the media type into v.
+func DecodeBody(c *gin.Context, mediaType string, v interface{}) error {
+	codec, ok := LookupCodec(mediaType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoCodec, mediaType)
+	}
+	return codec.Decode(c.Request.Body, v)
+}
+
+// Negotiate returns the media type most acceptable by the `Accept` header, or
+// the first one if the request accepts anything.
+func Negotiate(c *gin.Context, mediaTypes ...string) (string, error) {
+	accept := c.GetHeader("Accept")
+	if accept == "" {
+		return mediaTypes[0], nil
+	}
+
+	for _, r := range parseAccept(accept) {
+		for _, t := range mediaTypes {
+			// Media types could be ranges as well, e.g. `image/*`.
+			if MatchMediaType(t, r) || MatchMediaType(r, t) {
+				return t, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNotAcceptable, accept)
+}
+
+// Render writes the response body in the negotiated media type.
+func Render(c *gin.Context, status int, v interface{}, mediaTypes ...string) error {
+	t, err := Negotiate(c, mediaTypes...)
+	if err != nil {
+		// The operation has been negotiated already, it's one of the other
+		// responses which is not acceptable.
+		t = mediaTypes[0]
+	}
+
+	codec, ok := LookupCodec(t)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoCodec, t)
+	}
+
+	c.Header("Content-Type", t)
+	c.Status(status)
+	return codec.Encode(c.Writer, v)
+}
+
+// parseAccept returns the media ranges of the `Accept` header ordered by their
+// quality values, the unacceptable ones are dropped.
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		r string
+		q float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		r, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{r, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	ret := make([]string, len(ranges))
+	for i, r := range ranges {
+		ret[i] = r.r
+	}
+	return ret
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) Decode(r io.Reader, v interface{}) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+func (JSONCodec) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+type XMLCodec struct{}
+
+func (XMLCodec) Decode(r io.Reader, v interface{}) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+func (XMLCodec) Encode(w io.Writer, v interface{}) error {
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// TextCodec is the codec of `text/plain`, for strings, bytes and the types
+// implementing the text methods.
+type TextCodec struct{}
+
+func (TextCodec) Decode(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	switch x := v.(type) {
+	case *string:
+		*x = string(data)
+	case *[]byte:
+		*x = data
+	case encoding.TextUnmarshaler:
+		return x.UnmarshalText(data)
+	default:
+		return fmt.Errorf("%w: text/plain for %T", ErrNoCodec, v)
+	}
+	return nil
+}
+
+func (TextCodec) Encode(w io.Writer, v interface{}) error {
+	switch x := v.(type) {
+	case *string:
+		v = *x
+	case []byte:
+		_, err := w.Write(x)
+		return err
+	case encoding.TextMarshaler:
+		data, err := x.MarshalText()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	_, err := fmt.Fprint(w, v)
+	return err
+}
+
+// FormCodec is the codec of `application/x-www-form-urlencoded`, the form is
+// bound into the model by the `form` tags, the embedded structs included.
+type FormCodec struct{}
+
+func (FormCodec) Decode(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	return bindFormValues(form, reflect.ValueOf(v).Elem())
+}
+
+func (FormCodec) Encode(io.Writer, interface{}) error {
+	return fmt.Errorf("%w: encoding application/x-www-form-urlencoded", ErrNoCodec)
+}
@@ -0,0 +1,90 @@
+package detail
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrInvalidEnum = errors.New("invalid enum value")
+
+// Enum is implemented by the generated enum types.
+type Enum interface {
+	Valid() bool
+}
+
+// InvalidEnum reports the parameter has a value out of its enum.
+func InvalidEnum(name string, v interface{}) error {
+	return fmt.Errorf("%w: %s=%v", ErrInvalidEnum, name, v)
+}
+
+// ValidateEnums checks all the enum fields of the bound parameters, optional
+// fields and slices of enums included. Fields are named by their `form`,
+// `header` or `cookie` tags, and the embedded structs of shared parameters
+// are checked as well.
+func ValidateEnums(params interface{}) error {
+	v := reflect.ValueOf(params)
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := ValidateEnums(v.Field(i).Interface()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("form")
+		if !ok {
+			name, ok = field.Tag.Lookup("header")
+		}
+		if !ok {
+			name, ok = field.Tag.Lookup("cookie")
+		}
+		if !ok {
+			name = field.Name
+		}
+
+		if err := validateEnum(name, v.Field(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateEnum checks a single parameter bound by the detail package.
+func ValidateEnum(name string, v interface{}) error {
+	return validateEnum(name, reflect.ValueOf(v))
+}
+
+func validateEnum(name string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return validateEnum(name, v.Elem())
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateEnum(name, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateEnum(name, iter.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if e, ok := v.Interface().(Enum); ok && !e.Valid() {
+		return InvalidEnum(name, v.Interface())
+	}
+	return nil
+}
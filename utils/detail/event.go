@@ -0,0 +1,128 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrClientGone = errors.New("client gone")
+
+// EventStream sends the events of a `text/event-stream` response, the events
+// are written by the handler goroutine.
+type EventStream struct {
+	ctx    context.Context
+	events chan event
+}
+
+type event struct {
+	name string
+	data interface{}
+}
+
+// Send sends the event, strings are written as they are and the others in
+// JSON. It fails with ErrClientGone once the client disconnects.
+func (s *EventStream) Send(name string, data interface{}) error {
+	select {
+	case s.events <- event{name, data}:
+		return nil
+	case <-s.ctx.Done():
+		return fmt.Errorf("%w: %v", ErrClientGone, s.ctx.Err())
+	}
+}
+
+// Context is canceled once the client disconnects.
+func (s *EventStream) Context() context.Context {
+	return s.ctx
+}
+
+// ServeEvents runs fn in a new goroutine and writes its events to the client,
+// with heartbeat comments sent every interval unless it's 0. Nothing is written
+// until the first event, so if fn returns before that, its error is returned
+// with the response left to the caller. It returns the error of fn, except
+// those of the client gone.
+func ServeEvents(c *gin.Context, heartbeat time.Duration, fn func(*EventStream) error) error {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	s := &EventStream{ctx: ctx, events: make(chan event)}
+	done := make(chan error, 1)
+	panics := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panics <- r
+			}
+		}()
+		done <- fn(s)
+	}()
+
+	var first event
+	select {
+	case first = <-s.events:
+	case err := <-done:
+		return err
+	case r := <-panics:
+		panic(r)
+	case <-ctx.Done():
+		return stopEvents(cancel, done, panics)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Disables the response buffering of proxies like nginx.
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent(first.name, first.data)
+
+	var tick <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var err error
+	isDone := false
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case e := <-s.events:
+			c.SSEvent(e.name, e.data)
+			return true
+		case <-tick:
+			_, _ = io.WriteString(w, ": heartbeat\n\n")
+			return true
+		case err = <-done:
+			isDone = true
+			return false
+		case r := <-panics:
+			panic(r)
+		case <-ctx.Done():
+			return false
+		}
+	})
+	if isDone {
+		return err
+	}
+	return stopEvents(cancel, done, panics)
+}
+
+// stopEvents waits for fn to stop sending after the client has gone away.
+func stopEvents(cancel context.CancelFunc, done <-chan error, panics <-chan interface{}) error {
+	cancel()
+
+	var err error
+	select {
+	case err = <-done:
+	case r := <-panics:
+		panic(r)
+	}
+	if errors.Is(err, ErrClientGone) || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
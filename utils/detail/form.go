@@ -0,0 +1,128 @@
+package detail
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// BindForm binds the form field into v, repeated fields are bound into
+// slices.
+func BindForm(c *gin.Context, name string, v interface{}) error {
+	values, ok := c.GetPostFormArray(name)
+	if !ok {
+		return nil
+	}
+	rv := reflect.ValueOf(v).Elem()
+
+	switch kindOf(rv.Type()) {
+	case paramArray:
+		return setArray(name, rv, values)
+	case paramObject:
+		return fmt.Errorf("%w: %s of %s", ErrStyleBadType, name, rv.Type())
+	}
+	return setPrimitive(name, rv, values[0])
+}
+
+func bindFormValues(form url.Values, rv reflect.Value) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindFormValues(form, rv.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		values, ok := form[name]
+		if name == "" || name == "-" || !ok {
+			continue
+		}
+
+		var err error
+		switch kindOf(field.Type) {
+		case paramArray:
+			err = setArray(name, rv.Field(i), values)
+		case paramObject:
+			err = fmt.Errorf("%w: %s of %s", ErrStyleBadType, name, field.Type)
+		default:
+			err = setPrimitive(name, rv.Field(i), values[0])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FormFiles returns the file parts of the multipart form, and checks their
+// content types against the media ranges, e.g. `image/*`. Requests that are not
+// multipart forms are unsupported as well.
+func FormFiles(c *gin.Context, name string, mediaRanges ...string) ([]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedMediaType, err)
+	}
+
+	files := form.File[name]
+	if len(mediaRanges) == 0 {
+		return files, nil
+	}
+	for _, f := range files {
+		if t := f.Header.Get("Content-Type"); !MatchMediaType(t, mediaRanges...) {
+			return nil, fmt.Errorf("%w: part %q of %s", ErrUnsupportedMediaType, name, t)
+		}
+	}
+	return files, nil
+}
+
+// FormPart returns the content of the form part, either a field or a file.
+func FormPart(c *gin.Context, name string) ([]byte, bool, error) {
+	if v, ok := c.GetPostForm(name); ok {
+		return []byte(v), true, nil
+	}
+
+	f, err := c.FormFile(name)
+	if err != nil {
+		return nil, false, nil
+	}
+	r, err := f.Open()
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	return data, true, err
+}
+
+// MatchMediaType reports whether the media type is in any of the media
+// ranges, parameters of the media type are ignored.
+func MatchMediaType(mediaType string, mediaRanges ...string) bool {
+	t, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return false
+	}
+
+	for _, r := range mediaRanges {
+		r = strings.ToLower(strings.TrimSpace(r))
+		switch {
+		case r == "*/*", r == t:
+			return true
+		case strings.HasSuffix(r, "/*") && strings.HasPrefix(t, r[:len(r)-1]):
+			return true
+		}
+	}
+	return false
+}
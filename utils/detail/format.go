@@ -0,0 +1,59 @@
+package detail
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// DateLayout is the layout of the `date` format.
+const DateLayout = "2006-01-02"
+
+// Date is the type of the `date` format, only the date part of the time is
+// encoded.
+type Date struct {
+	time.Time
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Date) UnmarshalText(data []byte) (err error) {
+	*d, err = ParseDate(string(data))
+	return
+}
+
+// MarshalJSON overrides the RFC 3339 date-time of the embedded time.
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// URL is the type of the `uri` format.
+type URL struct {
+	url.URL
+}
+
+func (u URL) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u *URL) UnmarshalText(data []byte) (err error) {
+	*u, err = ParseURL(string(data))
+	return
+}
@@ -0,0 +1,54 @@
+package detail
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDateJSON(t *testing.T) {
+	type model struct {
+		Date    Date  `json:"date"`
+		Pointer *Date `json:"pointer"`
+	}
+
+	in := model{Date: Date{time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)}}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"date":"2020-01-02","pointer":null}`; string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+
+	var out model
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Date.Equal(in.Date.Time) || out.Pointer != nil {
+		t.Fatalf("got %+v, want %+v", out, in)
+	}
+
+	if err := json.Unmarshal([]byte(`{"date":"2020-01-02T00:00:00Z"}`), &out); err == nil {
+		t.Fatal("expected error of date-time")
+	}
+}
+
+func TestDateText(t *testing.T) {
+	d := Date{time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)}
+	text, err := d.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "2020-12-31" {
+		t.Fatalf("got %s", text)
+	}
+
+	var out Date
+	if err := out.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+	if !out.Equal(d.Time) {
+		t.Fatalf("got %v, want %v", out, d)
+	}
+}
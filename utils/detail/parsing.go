@@ -1,52 +1,166 @@
 package detail
 
 import (
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+var ErrMissingParam = errors.New("missing required parameter")
+
+// MissingParam reports the required parameter is not in the request.
+func MissingParam(name string) error {
+	return fmt.Errorf("%w: %s", ErrMissingParam, name)
+}
+
 func ParamString(c *gin.Context, k string) (string, error) {
-	return c.Param(k), nil
+	return ParseString(c.Param(k))
 }
 
 func ParamBool(c *gin.Context, k string) (bool, error) {
-	return strconv.ParseBool(c.Param(k))
+	return ParseBool(c.Param(k))
 }
 
 func ParamInt64(c *gin.Context, k string) (int64, error) {
-	return strconv.ParseInt(c.Param(k), 10, 64)
+	return ParseInt64(c.Param(k))
 }
 
 func ParamInt32(c *gin.Context, k string) (int32, error) {
-	v, err := ParamInt64(c, k)
-	return int32(v), err
+	return ParseInt32(c.Param(k))
 }
 
 func ParamInt(c *gin.Context, k string) (int, error) {
-	v, err := ParamInt64(c, k)
-	return int(v), err
+	return ParseInt(c.Param(k))
 }
 
 func ParamUint64(c *gin.Context, k string) (uint64, error) {
-	return strconv.ParseUint(c.Param(k), 10, 64)
+	return ParseUint64(c.Param(k))
 }
 
 func ParamUint32(c *gin.Context, k string) (uint32, error) {
-	v, err := ParamUint64(c, k)
-	return uint32(v), err
+	return ParseUint32(c.Param(k))
 }
 
 func ParamUint(c *gin.Context, k string) (uint, error) {
-	v, err := ParamUint64(c, k)
-	return uint(v), err
+	return ParseUint(c.Param(k))
 }
 
 func ParamFloat32(c *gin.Context, k string) (float32, error) {
-	v, err := ParamFloat64(c, k)
-	return float32(v), err
+	return ParseFloat32(c.Param(k))
 }
 
 func ParamFloat64(c *gin.Context, k string) (float64, error) {
-	return strconv.ParseFloat(c.Param(k), 64)
+	return ParseFloat64(c.Param(k))
+}
+
+func ParamTime(c *gin.Context, k string) (time.Time, error) {
+	return ParseTime(c.Param(k))
+}
+
+func ParamDate(c *gin.Context, k string) (Date, error) {
+	return ParseDate(c.Param(k))
+}
+
+func ParamUUID(c *gin.Context, k string) (uuid.UUID, error) {
+	return ParseUUID(c.Param(k))
+}
+
+func ParamBytes(c *gin.Context, k string) ([]byte, error) {
+	return ParseBytes(c.Param(k))
+}
+
+func ParamURL(c *gin.Context, k string) (URL, error) {
+	return ParseURL(c.Param(k))
+}
+
+func ParseString(s string) (string, error) {
+	return s, nil
+}
+
+func ParseBool(s string) (bool, error) {
+	return strconv.ParseBool(s)
+}
+
+func ParseInt64(s string) (int64, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func ParseInt32(s string) (int32, error) {
+	v, err := strconv.ParseInt(s, 10, 32)
+	return int32(v), err
+}
+
+func ParseInt(s string) (int, error) {
+	v, err := strconv.ParseInt(s, 10, 0)
+	return int(v), err
+}
+
+func ParseUint64(s string) (uint64, error) {
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func ParseUint32(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+	return uint32(v), err
+}
+
+func ParseUint(s string) (uint, error) {
+	v, err := strconv.ParseUint(s, 10, 0)
+	return uint(v), err
+}
+
+func ParseFloat32(s string) (float32, error) {
+	v, err := strconv.ParseFloat(s, 32)
+	return float32(v), err
+}
+
+func ParseFloat64(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+// ParseTime parses a `date-time` string, as defined by RFC 3339.
+func ParseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339, s)
+}
+
+// ParseDate parses a `date` string, as defined by RFC 3339 full-date.
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	return Date{t}, err
+}
+
+// ParseUUID parses a `uuid` string.
+func ParseUUID(s string) (uuid.UUID, error) {
+	return uuid.Parse(s)
+}
+
+// ParseBytes parses a `byte` string, which is base64-encoded.
+func ParseBytes(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// ParseURL parses a `uri` string.
+func ParseURL(s string) (URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return URL{}, err
+	}
+	return URL{*u}, nil
+}
+
+// ParamText binds the path variable of user-defined types.
+func ParamText(c *gin.Context, k string, v encoding.TextUnmarshaler) error {
+	return ParseText(c.Param(k), v)
+}
+
+// ParseText parses a string of user-defined types.
+func ParseText(s string, v encoding.TextUnmarshaler) error {
+	return v.UnmarshalText([]byte(s))
 }
@@ -7,4 +7,11 @@ type GinRegistry struct {
 	URL         string
 	Main        gin.HandlerFunc
 	Middlewares []gin.HandlerFunc
+
+	// HasImplicitHead is set for GET operations without a HEAD sibling, the
+	// main handler is then routed for HEAD requests as well.
+	HasImplicitHead bool
+
+	// Security is the requirements of the operation, any of them should be met.
+	Security []SecurityRequirement
 }
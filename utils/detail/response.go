@@ -0,0 +1,7 @@
+package detail
+
+import "errors"
+
+// ErrNoResponse is raised when a service method returns neither a response
+// nor an error.
+var ErrNoResponse = errors.New("no response returned")
@@ -0,0 +1,143 @@
+package detail
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrNoCredentials = errors.New("no credentials")
+	ErrForbidden     = errors.New("forbidden")
+	ErrNoScheme      = errors.New("no security scheme")
+)
+
+const (
+	// ScopesKey is the context key of the scopes granted to the request.
+	ScopesKey = "ginapi.scopes"
+	// RequirementKey is the context key of the security requirement met.
+	RequirementKey = "ginapi.requirement"
+)
+
+// Authenticator authenticates the request by a security scheme, with the
+// scopes required by the operation.
+type Authenticator func(c *gin.Context, scopes []string) error
+
+// SecurityRequirement is the scopes of each security scheme, all of them should
+// pass. An empty requirement passes anonymous requests.
+type SecurityRequirement map[string][]string
+
+// Authenticate returns the middleware passing requests that meet any of the
+// requirements. Failures abort with 403 if any scheme wraps ErrForbidden, or
+// 401 otherwise.
+func Authenticate(requirements []SecurityRequirement, schemes map[string]Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := http.StatusUnauthorized
+		var errs []string
+		for _, req := range requirements {
+			err := authenticate(c, req, schemes)
+			if err == nil {
+				c.Set(RequirementKey, req)
+				return
+			}
+			if errors.Is(err, ErrForbidden) {
+				status = http.StatusForbidden
+			}
+			errs = append(errs, err.Error())
+		}
+
+		_ = c.AbortWithError(status, errors.New(strings.Join(errs, "; ")))
+	}
+}
+
+func authenticate(c *gin.Context, req SecurityRequirement, schemes map[string]Authenticator) error {
+	names := make([]string, 0, len(req))
+	for name := range req {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		auth, ok := schemes[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrNoScheme, name)
+		}
+		if err := auth(c, req[name]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// MissingScopes returns the scopes required by the security requirement met
+// but not granted to the request, in the order of the schemes.
+func MissingScopes(c *gin.Context) []string {
+	v, ok := c.Get(RequirementKey)
+	if !ok {
+		return nil
+	}
+	req := v.(SecurityRequirement)
+
+	granted := map[string]struct{}{}
+	for _, s := range c.GetStringSlice(ScopesKey) {
+		granted[s] = struct{}{}
+	}
+
+	names := make([]string, 0, len(req))
+	for name := range req {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ret []string
+	for _, name := range names {
+		for _, s := range req[name] {
+			if _, ok := granted[s]; !ok {
+				granted[s] = struct{}{}
+				ret = append(ret, s)
+			}
+		}
+	}
+	return ret
+}
+
+// BearerToken returns the token of the `Authorization: Bearer` header.
+func BearerToken(c *gin.Context) (string, error) {
+	const prefix = "bearer "
+	h := c.GetHeader("Authorization")
+	if len(h) <= len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", ErrNoCredentials
+	}
+	return strings.TrimSpace(h[len(prefix):]), nil
+}
+
+// BasicAuth returns the username and password of the `Authorization: Basic`
+// header.
+func BasicAuth(c *gin.Context) (string, string, error) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok {
+		return "", "", ErrNoCredentials
+	}
+	return username, password, nil
+}
+
+// APIKey returns the API key in the header, query or cookie.
+func APIKey(c *gin.Context, in, name string) (string, error) {
+	var key string
+	switch in {
+	case "header":
+		key = c.GetHeader(name)
+	case "query":
+		key = c.Query(name)
+	case "cookie":
+		key, _ = c.Cookie(name)
+	}
+	if key == "" {
+		return "", ErrNoCredentials
+	}
+	return key, nil
+}
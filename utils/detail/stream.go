@@ -0,0 +1,102 @@
+package detail
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mimeOctetStream = "application/octet-stream"
+
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// LimitBody limits the request body to n bytes by `http.MaxBytesReader`, reading
+// beyond the limit fails with ErrBodyTooLarge. Requests with a larger content
+// length fail at once.
+func LimitBody(c *gin.Context, n int64) error {
+	if c.Request.ContentLength > n {
+		return fmt.Errorf("%w: %d bytes", ErrBodyTooLarge, c.Request.ContentLength)
+	}
+	c.Request.Body = &limitedBody{
+		ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, n),
+		limit:      n,
+	}
+	return nil
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	limit int64
+	read  int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err != nil && err != io.EOF && b.read >= b.limit {
+		err = fmt.Errorf("%w: %v", ErrBodyTooLarge, err)
+	}
+	return n, err
+}
+
+// Stream is a streaming response body along with its metadata, all optional.
+type Stream struct {
+	io.ReadCloser
+
+	// Length is the content length, unknown if not positive.
+	Length int64
+	// Type is the content type, negotiated by the media types if empty.
+	Type string
+	// Filename makes the body an attachment by `Content-Disposition`.
+	Filename string
+}
+
+// RenderStream copies the body to the response without buffering it, and
+// closes the body. The metadata is taken from the body of either Stream or
+// *Stream.
+func RenderStream(c *gin.Context, status int, body io.ReadCloser, mediaTypes ...string) {
+	var s Stream
+	switch x := body.(type) {
+	case *Stream:
+		if x != nil {
+			s = *x
+		}
+	case Stream:
+		s = x
+	default:
+		s.ReadCloser = body
+	}
+	if s.ReadCloser == nil {
+		c.Status(status)
+		return
+	}
+	defer s.Close()
+
+	length := int64(-1)
+	if s.Length > 0 {
+		length = s.Length
+	}
+	contentType := s.Type
+	headers := map[string]string{}
+	if s.Filename != "" {
+		headers["Content-Disposition"] = mime.FormatMediaType("attachment", map[string]string{
+			"filename": s.Filename,
+		})
+	}
+
+	if contentType == "" {
+		t, err := Negotiate(c, mediaTypes...)
+		if err != nil || strings.Contains(t, "*") {
+			// Media ranges like `image/*` are no content types.
+			t = mimeOctetStream
+		}
+		contentType = t
+	}
+
+	c.DataFromReader(status, length, contentType, s.ReadCloser, headers)
+}
@@ -0,0 +1,500 @@
+package detail
+
+import (
+	"encoding"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Styles of the parameter serialization, see
+// https://swagger.io/docs/specification/serialization/.
+const (
+	StyleForm           = "form"
+	StyleSpaceDelimited = "spaceDelimited"
+	StylePipeDelimited  = "pipeDelimited"
+	StyleDeepObject     = "deepObject"
+	StyleSimple         = "simple"
+	StyleLabel          = "label"
+	StyleMatrix         = "matrix"
+)
+
+var (
+	ErrStyleBadParam = errors.New("bad serialized parameter")
+	ErrStyleBadType  = errors.New("unsupported parameter type")
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+type paramKind int
+
+const (
+	paramPrimitive paramKind = iota
+	paramArray
+	paramObject
+)
+
+// BindQuery binds the query parameter serialized in the style into v, which
+// is a pointer to a primitive, a slice, a map or a struct with `json` tags.
+// The other queries are not taken as the properties of exploded form objects.
+func BindQuery(c *gin.Context, name, style string, explode bool, v interface{}, others ...string) error {
+	query := c.Request.URL.Query()
+	rv := reflect.ValueOf(v).Elem()
+
+	switch kindOf(rv.Type()) {
+	case paramArray:
+		values, ok := query[name]
+		if !ok {
+			return nil
+		}
+		if !explode || style != StyleForm {
+			if values[0] == "" {
+				// Empty arrays, e.g. `ids=`.
+				values = nil
+			} else {
+				values = strings.Split(values[0], styleDelimiter(style))
+			}
+		}
+		return setArray(name, rv, values)
+	case paramObject:
+		obj := map[string]string{}
+		switch {
+		case style == StyleDeepObject:
+			prefix := name + "["
+			for k, values := range query {
+				if strings.HasPrefix(k, prefix) && strings.HasSuffix(k, "]") {
+					obj[k[len(prefix):len(k)-1]] = values[0]
+				}
+			}
+		case explode:
+			// Properties are the parameters themselves.
+			for k, values := range query {
+				obj[k] = values[0]
+			}
+			for _, k := range others {
+				delete(obj, k)
+			}
+		default:
+			s, ok := query[name]
+			if !ok {
+				return nil
+			}
+			var err error
+			obj, err = pairs(name, strings.Split(s[0], styleDelimiter(style)))
+			if err != nil {
+				return err
+			}
+		}
+		if len(obj) == 0 {
+			return nil
+		}
+		return setObject(name, rv, obj)
+	}
+
+	s, ok := c.GetQuery(name)
+	if !ok {
+		return nil
+	}
+	return setPrimitive(name, rv, s)
+}
+
+// BindPath binds the path variable serialized in the style of simple, label
+// or matrix into v.
+func BindPath(c *gin.Context, name, style string, explode bool, v interface{}) error {
+	s := c.Param(name)
+	rv := reflect.ValueOf(v).Elem()
+	kind := kindOf(rv.Type())
+
+	// Delimiter of the values, and whether the values are prefixed by the name.
+	delimiter := ","
+	switch style {
+	case StyleLabel:
+		if !strings.HasPrefix(s, ".") {
+			return fmt.Errorf("%w: %s=%q: expected label", ErrStyleBadParam, name, s)
+		}
+		s = s[1:]
+		if explode {
+			delimiter = "."
+		}
+	case StyleMatrix:
+		if !strings.HasPrefix(s, ";") {
+			return fmt.Errorf("%w: %s=%q: expected matrix", ErrStyleBadParam, name, s)
+		}
+		s = s[1:]
+		if explode && kind != paramPrimitive {
+			delimiter = ";"
+		}
+		if !explode || kind != paramObject {
+			// Exploded objects have the properties as names instead.
+			prefix := name + "="
+			if kind == paramArray && explode {
+				return bindMatrixArray(name, rv, s, prefix)
+			}
+			if !strings.HasPrefix(s, prefix) {
+				return fmt.Errorf("%w: %s=%q: expected %q", ErrStyleBadParam, name, s, prefix)
+			}
+			s = s[len(prefix):]
+		}
+	}
+
+	switch kind {
+	case paramArray:
+		return setArray(name, rv, strings.Split(s, delimiter))
+	case paramObject:
+		obj, err := objectOf(name, strings.Split(s, delimiter), explode)
+		if err != nil {
+			return err
+		}
+		return setObject(name, rv, obj)
+	}
+	return setPrimitive(name, rv, s)
+}
+
+// BindHeader binds the header serialized in the simple style into v.
+func BindHeader(c *gin.Context, name string, explode bool, v interface{}) error {
+	s := c.GetHeader(name)
+	if s == "" {
+		return nil
+	}
+	rv := reflect.ValueOf(v).Elem()
+
+	parts := strings.Split(s, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+
+	switch kindOf(rv.Type()) {
+	case paramArray:
+		return setArray(name, rv, parts)
+	case paramObject:
+		obj, err := objectOf(name, parts, explode)
+		if err != nil {
+			return err
+		}
+		return setObject(name, rv, obj)
+	}
+	return setPrimitive(name, rv, s)
+}
+
+func bindMatrixArray(name string, rv reflect.Value, s, prefix string) error {
+	parts := strings.Split(s, ";")
+	for i, part := range parts {
+		if !strings.HasPrefix(part, prefix) {
+			return fmt.Errorf("%w: %s=%q: expected %q", ErrStyleBadParam, name, s, prefix)
+		}
+		parts[i] = part[len(prefix):]
+	}
+	return setArray(name, rv, parts)
+}
+
+func styleDelimiter(style string) string {
+	switch style {
+	case StyleSpaceDelimited:
+		return " "
+	case StylePipeDelimited:
+		return "|"
+	}
+	return ","
+}
+
+// objectOf reads the properties from either `k=v` parts when exploded, or
+// `k,v` pairs.
+func objectOf(name string, parts []string, explode bool) (map[string]string, error) {
+	if !explode {
+		return pairs(name, parts)
+	}
+
+	obj := make(map[string]string, len(parts))
+	for _, part := range parts {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%w: %s: property %q", ErrStyleBadParam, name, part)
+		}
+		obj[kv[0]] = kv[1]
+	}
+	return obj, nil
+}
+
+func pairs(name string, parts []string) (map[string]string, error) {
+	if len(parts)%2 != 0 {
+		return nil, fmt.Errorf("%w: %s: odd number of keys and values", ErrStyleBadParam, name)
+	}
+
+	obj := make(map[string]string, len(parts)/2)
+	for i := 0; i < len(parts); i += 2 {
+		obj[parts[i]] = parts[i+1]
+	}
+	return obj, nil
+}
+
+func kindOf(t reflect.Type) paramKind {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return paramPrimitive
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Base64-encoded bytes.
+			return paramPrimitive
+		}
+		return paramArray
+	case reflect.Map, reflect.Struct:
+		return paramObject
+	}
+	return paramPrimitive
+}
+
+// settable allocates the optional value.
+func settable(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func setArray(name string, rv reflect.Value, values []string) error {
+	rv = settable(rv)
+	slice := reflect.MakeSlice(rv.Type(), len(values), len(values))
+	for i, s := range values {
+		if err := setPrimitive(name, slice.Index(i), s); err != nil {
+			return err
+		}
+	}
+	rv.Set(slice)
+	return nil
+}
+
+func setObject(name string, rv reflect.Value, obj map[string]string) error {
+	rv = settable(rv)
+	t := rv.Type()
+
+	if t.Kind() == reflect.Map {
+		m := reflect.MakeMapWithSize(t, len(obj))
+		for k, s := range obj {
+			v := reflect.New(t.Elem()).Elem()
+			if err := setPrimitive(name+"."+k, v, s); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), v)
+		}
+		rv.Set(m)
+		return nil
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		prop := strings.Split(field.Tag.Get("json"), ",")[0]
+		if prop == "" {
+			prop = field.Name
+		}
+		s, ok := obj[prop]
+		if prop == "-" || !ok {
+			continue
+		}
+		if err := setPrimitive(name+"."+prop, rv.Field(i), s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setPrimitive(name string, rv reflect.Value, s string) error {
+	rv = settable(rv)
+
+	if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("%w: %s=%q: %v", ErrStyleBadParam, name, s, err)
+		}
+		return nil
+	}
+
+	var err error
+	switch t := rv.Type(); t.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(s, 10, t.Bits())
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(s, 10, t.Bits())
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, t.Bits())
+		rv.SetFloat(f)
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("%w: %s of %s", ErrStyleBadType, name, t)
+		}
+		var b []byte
+		b, err = base64.StdEncoding.DecodeString(s)
+		rv.SetBytes(b)
+	default:
+		return fmt.Errorf("%w: %s of %s", ErrStyleBadType, name, t)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s=%q: %v", ErrStyleBadParam, name, s, err)
+	}
+	return nil
+}
+
+// WriteHeader writes the response header serialized in the simple style, nil
+// values are omitted.
+func WriteHeader(c *gin.Context, name string, explode bool, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	var parts []string
+	switch kindOf(rv.Type()) {
+	case paramArray:
+		for i := 0; i < rv.Len(); i++ {
+			s, err := formatPrimitive(name, rv.Index(i))
+			if err != nil {
+				return err
+			}
+			parts = append(parts, s)
+		}
+	case paramObject:
+		obj, err := formatObject(name, rv)
+		if err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if explode {
+				parts = append(parts, k+"="+obj[k])
+			} else {
+				parts = append(parts, k, obj[k])
+			}
+		}
+	default:
+		s, err := formatPrimitive(name, rv)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, s)
+	}
+
+	c.Header(name, strings.Join(parts, ","))
+	return nil
+}
+
+// SetCookies sets the cookies of the object, named by the properties and with
+// the path `/`, nil values are omitted.
+func SetCookies(c *gin.Context, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	obj, err := formatObject("Set-Cookie", rv)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(obj))
+	for k := range obj {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		http.SetCookie(c.Writer, &http.Cookie{Name: k, Value: obj[k], Path: "/"})
+	}
+	return nil
+}
+
+func formatObject(name string, rv reflect.Value) (map[string]string, error) {
+	obj := map[string]string{}
+
+	if rv.Kind() == reflect.Map {
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			s, err := formatPrimitive(name+"."+k, iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = s
+		}
+		return obj, nil
+	}
+
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		prop := strings.Split(field.Tag.Get("json"), ",")[0]
+		if prop == "" {
+			prop = field.Name
+		}
+		v := rv.Field(i)
+		if prop == "-" || v.Kind() == reflect.Ptr && v.IsNil() {
+			continue
+		}
+		s, err := formatPrimitive(name+"."+prop, v)
+		if err != nil {
+			return nil, err
+		}
+		obj[prop] = s
+	}
+	return obj, nil
+}
+
+func formatPrimitive(name string, rv reflect.Value) (string, error) {
+	for rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	if m, ok := rv.Interface().(encoding.TextMarshaler); ok {
+		data, err := m.MarshalText()
+		return string(data), err
+	}
+
+	switch t := rv.Type(); t.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, t.Bits()), nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(rv.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s of %s", ErrStyleBadType, name, rv.Type())
+}
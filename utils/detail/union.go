@@ -0,0 +1,65 @@
+package detail
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrUnionNoVariant            = errors.New("no variant matched")
+	ErrUnionAmbiguous            = errors.New("more than one variant matched")
+	ErrUnionUnknownDiscriminator = errors.New("unknown discriminator value")
+)
+
+// DiscriminatorValue reads the discriminator property from a JSON object.
+func DiscriminatorValue(data []byte, property string) (string, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", err
+	}
+
+	raw, ok := obj[property]
+	if !ok {
+		return "", fmt.Errorf("%w: missing property %q", ErrUnionUnknownDiscriminator, property)
+	}
+
+	var v string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", fmt.Errorf("%w: property %q: %v", ErrUnionUnknownDiscriminator, property, err)
+	}
+	return v, nil
+}
+
+// UnknownDiscriminator reports the discriminator value matches no variants.
+func UnknownDiscriminator(v string) error {
+	return fmt.Errorf("%w: %q", ErrUnionUnknownDiscriminator, v)
+}
+
+// StrictUnmarshal is json.Unmarshal that disallows unknown fields, so that
+// variants of a union without a discriminator could be told apart.
+func StrictUnmarshal(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// CheckOneOf checks the number of matched variants of a oneOf union.
+func CheckOneOf(n int) error {
+	switch {
+	case n == 0:
+		return ErrUnionNoVariant
+	case n > 1:
+		return ErrUnionAmbiguous
+	}
+	return nil
+}
+
+// CheckAnyOf checks the number of matched variants of an anyOf union.
+func CheckAnyOf(n int) error {
+	if n == 0 {
+		return ErrUnionNoVariant
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package ginapiutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sync"
+)
+
+var (
+	ErrBadJWK         = errors.New("bad JSON web key")
+	ErrUnsupportedJWK = errors.New("unsupported JSON web key")
+)
+
+// KeySet is the verification keys of JWTs by their key IDs, which is safe to
+// reload while verifying. Keys are `*rsa.PublicKey` for RS256,
+// `*ecdsa.PublicKey` for ES256 and `[]byte` for HS256. The zero value is an
+// empty key set.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys map[string]*jwk
+	// Keys without key IDs, tried for the tokens without key IDs only.
+	anonymous []*jwk
+}
+
+type jwk struct {
+	alg string
+	key interface{}
+}
+
+// NewKeySet returns an empty key set.
+func NewKeySet() *KeySet {
+	return &KeySet{keys: map[string]*jwk{}}
+}
+
+// LoadKeySetFile returns the key set of the JWKS file.
+func LoadKeySetFile(filename string) (*KeySet, error) {
+	s := NewKeySet()
+	if err := s.LoadFile(filename); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadFile replaces all the keys with the ones of the JWKS file.
+func (s *KeySet) LoadFile(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return s.Load(data)
+}
+
+// Load replaces all the keys with the ones of the JWKS document, keys not used
+// for signatures or of unsupported types, curves and algorithms are skipped,
+// unless none of the keys is left.
+func (s *KeySet) Load(data []byte) error {
+	var doc struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadJWK, err)
+	}
+
+	keys := map[string]*jwk{}
+	var (
+		anonymous   []*jwk
+		unsupported error
+	)
+	for i, raw := range doc.Keys {
+		if use, _ := raw["use"].(string); use != "" && use != "sig" {
+			continue
+		}
+		k, err := parseJWK(raw)
+		if errors.Is(err, ErrUnsupportedJWK) {
+			unsupported = fmt.Errorf("key %d: %w", i, err)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("key %d: %w", i, err)
+		}
+		if kid, _ := raw["kid"].(string); kid != "" {
+			keys[kid] = k
+		} else {
+			anonymous = append(anonymous, k)
+		}
+	}
+	if unsupported != nil && len(keys) == 0 && len(anonymous) == 0 {
+		return unsupported
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.anonymous = anonymous
+	return nil
+}
+
+// Set adds or replaces the key of the key ID, keys of the empty key ID are
+// added along with the existing ones.
+func (s *KeySet) Set(kid string, key interface{}) error {
+	k := &jwk{key: key}
+	switch key.(type) {
+	case *rsa.PublicKey:
+		k.alg = algRS256
+	case *ecdsa.PublicKey:
+		k.alg = algES256
+	case []byte:
+		k.alg = algHS256
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedJWK, key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kid == "" {
+		s.anonymous = append(s.anonymous, k)
+		return nil
+	}
+	if s.keys == nil {
+		s.keys = map[string]*jwk{}
+	}
+	s.keys[kid] = k
+	return nil
+}
+
+// Delete removes the key of the key ID, or all the keys without key IDs if
+// empty.
+func (s *KeySet) Delete(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if kid == "" {
+		s.anonymous = nil
+		return
+	}
+	delete(s.keys, kid)
+}
+
+// lookup returns the keys of the algorithm, only the one of the key ID if
+// given.
+func (s *KeySet) lookup(alg, kid string) []interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid != "" {
+		if k, ok := s.keys[kid]; ok && k.alg == alg {
+			return []interface{}{k.key}
+		}
+		return nil
+	}
+
+	var ret []interface{}
+	for _, k := range s.keys {
+		if k.alg == alg {
+			ret = append(ret, k.key)
+		}
+	}
+	for _, k := range s.anonymous {
+		if k.alg == alg {
+			ret = append(ret, k.key)
+		}
+	}
+	return ret
+}
+
+func parseJWK(raw map[string]interface{}) (*jwk, error) {
+	kty, _ := raw["kty"].(string)
+	alg, _ := raw["alg"].(string)
+
+	field := func(name string) ([]byte, error) {
+		s, _ := raw[name].(string)
+		if s == "" {
+			return nil, fmt.Errorf("%w: no %q of %s key", ErrBadJWK, name, kty)
+		}
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q of %s key: %v", ErrBadJWK, name, kty, err)
+		}
+		return b, nil
+	}
+
+	k := &jwk{}
+	switch kty {
+	case "RSA":
+		n, err := field("n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := field("e")
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 2 || exp.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("%w: bad RSA exponent", ErrBadJWK)
+		}
+		k.alg = algRS256
+		k.key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}
+	case "EC":
+		if crv, _ := raw["crv"].(string); crv != "P-256" {
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedJWK, crv)
+		}
+		x, err := field("x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := field("y")
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("%w: EC point not on curve", ErrBadJWK)
+		}
+		k.alg = algES256
+		k.key = key
+	case "oct":
+		secret, err := field("k")
+		if err != nil {
+			return nil, err
+		}
+		k.alg = algHS256
+		k.key = secret
+	default:
+		return nil, fmt.Errorf("%w: key type %q", ErrUnsupportedJWK, kty)
+	}
+
+	if alg != "" && alg != k.alg {
+		return nil, fmt.Errorf("%w: algorithm %q of %s key", ErrUnsupportedJWK, alg, kty)
+	}
+	return k, nil
+}
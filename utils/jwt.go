@@ -0,0 +1,275 @@
+package ginapiutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	algRS256 = "RS256"
+	algES256 = "ES256"
+	algHS256 = "HS256"
+
+	claimsKey = "ginapi.claims"
+)
+
+var (
+	ErrJWTMalformed   = errors.New("malformed JWT")
+	ErrJWTAlgorithm   = errors.New("unsupported JWT algorithm")
+	ErrJWTNoKey       = errors.New("no key for JWT")
+	ErrJWTSignature   = errors.New("bad JWT signature")
+	ErrJWTExpired     = errors.New("JWT expired")
+	ErrJWTNotYetValid = errors.New("JWT not valid yet")
+	ErrJWTBadIssuer   = errors.New("bad JWT issuer")
+	ErrJWTBadAudience = errors.New("bad JWT audience")
+)
+
+// Claims is the payload of a verified JWT.
+type Claims map[string]interface{}
+
+// Subject returns the `sub` claim.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// Scopes returns the space-separated `scope` claim, or the `scp` claim as a
+// list.
+func (c Claims) Scopes() []string {
+	if s, ok := c["scope"].(string); ok {
+		return strings.Fields(s)
+	}
+	return stringsClaim(c["scp"])
+}
+
+// JWTVerifier verifies RS256, ES256 and HS256 JWTs with the local keys, no
+// network calls are made. Tokens fail with ErrJWTNoKey if Keys is nil.
+type JWTVerifier struct {
+	Keys *KeySet
+
+	// Issuer is checked against the `iss` claim if not empty.
+	Issuer string
+	// Audience should be one of the `aud` claim if not empty.
+	Audience string
+	// Leeway tolerates the clock skew of `exp` and `nbf`.
+	Leeway time.Duration
+	// Now defaults to `time.Now`.
+	Now func() time.Time
+}
+
+// Verify verifies the signature and the registered claims of the token, the
+// `exp` and `nbf` claims are checked only if present.
+func (v *JWTVerifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrJWTMalformed, err)
+	}
+
+	switch header.Alg {
+	case algRS256, algES256, algHS256:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrJWTAlgorithm, header.Alg)
+	}
+	var keys []interface{}
+	if v.Keys != nil {
+		keys = v.Keys.lookup(header.Alg, header.Kid)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: %s key %q", ErrJWTNoKey, header.Alg, header.Kid)
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if verifySignature(header.Alg, key, signed, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrJWTSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if err := v.validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Authenticate verifies the bearer token and puts the claims and scopes on the
+// context, which fits the bearer methods of the generated `SecurityHandler`.
+func (v *JWTVerifier) Authenticate(c *gin.Context, token string) error {
+	claims, err := v.Verify(token)
+	if err != nil {
+		return err
+	}
+	c.Set(claimsKey, claims)
+	SetScopes(c, claims.Scopes()...)
+	return nil
+}
+
+// AuthenticateOAuth2 is Authenticate for the OAuth2 methods of the generated
+// `SecurityHandler`, tokens without the required scopes fail with 403.
+func (v *JWTVerifier) AuthenticateOAuth2(c *gin.Context, token string, scopes []string) error {
+	if err := v.Authenticate(c, token); err != nil {
+		return err
+	}
+
+	granted := map[string]struct{}{}
+	for _, s := range Scopes(c) {
+		granted[s] = struct{}{}
+	}
+	var missing []string
+	for _, s := range scopes {
+		if _, ok := granted[s]; !ok {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %v: %s", ErrForbidden, ErrInsufficientScope, strings.Join(missing, " "))
+	}
+	return nil
+}
+
+// ClaimsOf returns the claims put on the context by the verifier.
+func ClaimsOf(c *gin.Context) (Claims, bool) {
+	v, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(Claims)
+	return claims, ok
+}
+
+func (v *JWTVerifier) validate(claims Claims) error {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+
+	if exp, ok, err := timeClaim(claims, "exp"); err != nil {
+		return err
+	} else if ok && !now.Before(exp.Add(v.Leeway)) {
+		return ErrJWTExpired
+	}
+	if nbf, ok, err := timeClaim(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(v.Leeway).Before(nbf) {
+		return ErrJWTNotYetValid
+	}
+
+	if v.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.Issuer {
+			return fmt.Errorf("%w: %q", ErrJWTBadIssuer, iss)
+		}
+	}
+	if v.Audience != "" {
+		ok := false
+		for _, aud := range stringsClaim(claims["aud"]) {
+			if aud == v.Audience {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return ErrJWTBadAudience
+		}
+	}
+	return nil
+}
+
+func verifySignature(alg string, key interface{}, signed, sig []byte) bool {
+	digest := sha256.Sum256(signed)
+
+	switch alg {
+	case algRS256:
+		k, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	case algES256:
+		k, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(k, digest[:], r, s)
+	case algHS256:
+		k, ok := key.([]byte)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), sig)
+	}
+	return false
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrJWTMalformed, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrJWTMalformed, err)
+	}
+	return nil
+}
+
+func timeClaim(claims Claims, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %q is not a number", ErrJWTMalformed, name)
+	}
+	sec := int64(n)
+	return time.Unix(sec, int64((n-float64(sec))*1e9)), true, nil
+}
+
+// stringsClaim returns the claim of a string or a list of strings.
+func stringsClaim(v interface{}) []string {
+	switch x := v.(type) {
+	case string:
+		return []string{x}
+	case []interface{}:
+		ret := make([]string, 0, len(x))
+		for _, s := range x {
+			if s, ok := s.(string); ok {
+				ret = append(ret, s)
+			}
+		}
+		return ret
+	}
+	return nil
+}
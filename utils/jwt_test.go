@@ -0,0 +1,309 @@
+package ginapiutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	testECKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testSecret    = []byte("secret")
+	testNow       = time.Unix(1600000000, 0)
+)
+
+func signJWT(t *testing.T, header, claims map[string]interface{}, key interface{}) string {
+	t.Helper()
+
+	seg := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := seg(header) + "." + seg(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func testKeySet(t *testing.T) *KeySet {
+	t.Helper()
+
+	s := NewKeySet()
+	for kid, key := range map[string]interface{}{
+		"rsa": &testRSAKey.PublicKey,
+		"ec":  &testECKey.PublicKey,
+		"hs":  testSecret,
+	} {
+		if err := s.Set(kid, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestJWTVerify(t *testing.T) {
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&testRSAKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := map[string]interface{}{"sub": "alice", "aud": "api"}
+	header := func(alg, kid string) map[string]interface{} {
+		return map[string]interface{}{"alg": alg, "kid": kid}
+	}
+	at := func(d time.Duration) float64 {
+		return float64(testNow.Add(d).Unix())
+	}
+
+	tampered := signJWT(t, header(algRS256, "rsa"), claims, testRSAKey)
+	parts := strings.Split(tampered, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"mallory","aud":"api"}`))
+	tampered = strings.Join(parts, ".")
+
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice"}`)) + "."
+
+	tests := []struct {
+		name   string
+		token  string
+		leeway time.Duration
+		err    error
+	}{
+		{"RS256", signJWT(t, header(algRS256, "rsa"), claims, testRSAKey), 0, nil},
+		{"ES256", signJWT(t, header(algES256, "ec"), claims, testECKey), 0, nil},
+		{"HS256", signJWT(t, header(algHS256, "hs"), claims, testSecret), 0, nil},
+		{"tampered payload", tampered, 0, ErrJWTSignature},
+		{"alg none", none, 0, ErrJWTAlgorithm},
+		{
+			"HS256 by RSA public key",
+			signJWT(t, header(algHS256, "rsa"), claims, rsaPublic),
+			0, ErrJWTNoKey,
+		},
+		{
+			"HS256 by RSA public key without kid",
+			signJWT(t, header(algHS256, ""), claims, rsaPublic),
+			0, ErrJWTSignature,
+		},
+		{"mismatched kid", signJWT(t, header(algRS256, "ec"), claims, testRSAKey), 0, ErrJWTNoKey},
+		{"unknown kid", signJWT(t, header(algRS256, "other"), claims, testRSAKey), 0, ErrJWTNoKey},
+		{
+			"expired",
+			signJWT(t, header(algHS256, "hs"), map[string]interface{}{"exp": at(-time.Minute)}, testSecret),
+			0, ErrJWTExpired,
+		},
+		{
+			"expired within leeway",
+			signJWT(t, header(algHS256, "hs"), map[string]interface{}{"exp": at(-time.Minute)}, testSecret),
+			2 * time.Minute, nil,
+		},
+		{
+			"expired outside leeway",
+			signJWT(t, header(algHS256, "hs"), map[string]interface{}{"exp": at(-time.Hour)}, testSecret),
+			2 * time.Minute, ErrJWTExpired,
+		},
+		{
+			"not yet valid",
+			signJWT(t, header(algHS256, "hs"), map[string]interface{}{"nbf": at(time.Minute)}, testSecret),
+			0, ErrJWTNotYetValid,
+		},
+		{
+			"not yet valid within leeway",
+			signJWT(t, header(algHS256, "hs"), map[string]interface{}{"nbf": at(time.Minute)}, testSecret),
+			2 * time.Minute, nil,
+		},
+		{
+			"not yet valid outside leeway",
+			signJWT(t, header(algHS256, "hs"), map[string]interface{}{"nbf": at(time.Hour)}, testSecret),
+			2 * time.Minute, ErrJWTNotYetValid,
+		},
+		{"malformed", "a.b", 0, ErrJWTMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &JWTVerifier{
+				Keys:   testKeySet(t),
+				Leeway: tt.leeway,
+				Now:    func() time.Time { return testNow },
+			}
+			_, err := v.Verify(tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestJWTAudience(t *testing.T) {
+	tests := []struct {
+		name string
+		aud  interface{}
+		err  error
+	}{
+		{"string", "api", nil},
+		{"array", []string{"web", "api"}, nil},
+		{"other string", "web", ErrJWTBadAudience},
+		{"other array", []string{"web", "cli"}, ErrJWTBadAudience},
+		{"missing", nil, ErrJWTBadAudience},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]interface{}{}
+			if tt.aud != nil {
+				claims["aud"] = tt.aud
+			}
+			token := signJWT(t, map[string]interface{}{"alg": algHS256, "kid": "hs"}, claims, testSecret)
+
+			v := &JWTVerifier{Keys: testKeySet(t), Audience: "api"}
+			_, err := v.Verify(token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestJWTKeysWithoutKid(t *testing.T) {
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s KeySet
+	if err := s.Set("", &other.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("", &testRSAKey.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	v := &JWTVerifier{Keys: &s}
+	token := signJWT(t, map[string]interface{}{"alg": algRS256}, map[string]interface{}{}, testRSAKey)
+	if _, err := v.Verify(token); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeySetLoadWithoutKid(t *testing.T) {
+	k := func(secret string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(secret))
+	}
+	doc := `{"keys": [
+		{"kty": "oct", "k": "` + k("old") + `"},
+		{"kty": "oct", "k": "` + k("new") + `"},
+		{"kty": "oct", "k": "` + k("enc") + `", "use": "enc"}
+	]}`
+
+	s := NewKeySet()
+	if err := s.Load([]byte(doc)); err != nil {
+		t.Fatal(err)
+	}
+
+	v := &JWTVerifier{Keys: s}
+	for _, secret := range []string{"old", "new"} {
+		token := signJWT(t, map[string]interface{}{"alg": algHS256}, map[string]interface{}{}, []byte(secret))
+		if _, err := v.Verify(token); err != nil {
+			t.Fatalf("%s: %v", secret, err)
+		}
+	}
+	token := signJWT(t, map[string]interface{}{"alg": algHS256}, map[string]interface{}{}, []byte("enc"))
+	if _, err := v.Verify(token); !errors.Is(err, ErrJWTSignature) {
+		t.Fatalf("got %v, want %v", err, ErrJWTSignature)
+	}
+}
+
+func TestJWTNoKeys(t *testing.T) {
+	token := signJWT(t, map[string]interface{}{"alg": algHS256}, map[string]interface{}{}, testSecret)
+	for _, v := range []*JWTVerifier{{}, {Keys: &KeySet{}}} {
+		if _, err := v.Verify(token); !errors.Is(err, ErrJWTNoKey) {
+			t.Fatalf("got %v, want %v", err, ErrJWTNoKey)
+		}
+	}
+}
+
+func TestKeySetLoadUnsupported(t *testing.T) {
+	k := func(secret string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(secret))
+	}
+	b := func(n *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(n.Bytes())
+	}
+	rsaKey := `{"kty": "RSA", "kid": "rsa", "n": "` + b(testRSAKey.N) + `", "e": "` +
+		b(big.NewInt(int64(testRSAKey.E))) + `"}`
+	unsupported := []string{
+		`{"kty": "RSA", "kid": "rs384", "alg": "RS384", "n": "` + b(testRSAKey.N) + `", "e": "AQAB"}`,
+		`{"kty": "EC", "kid": "p384", "crv": "P-384", "x": "AA", "y": "AA"}`,
+		`{"kty": "OKP", "kid": "ed25519", "crv": "Ed25519", "x": "AA"}`,
+	}
+
+	tests := []struct {
+		name string
+		keys []string
+		err  error
+	}{
+		{"mixed", append([]string{rsaKey, `{"kty": "oct", "kid": "hs", "k": "` + k("secret") + `"}`}, unsupported...), nil},
+		{"unsupported only", unsupported, ErrUnsupportedJWK},
+		{"empty", nil, nil},
+		{"malformed", []string{`{"kty": "oct", "kid": "hs"}`}, ErrBadJWK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewKeySet()
+			err := s.Load([]byte(`{"keys": [` + strings.Join(tt.keys, ",") + `]}`))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+			if err != nil || len(tt.keys) == 0 {
+				return
+			}
+
+			v := &JWTVerifier{Keys: s}
+			for kid, key := range map[string]interface{}{"rsa": testRSAKey, "hs": testSecret} {
+				alg := algRS256
+				if kid == "hs" {
+					alg = algHS256
+				}
+				token := signJWT(t, map[string]interface{}{"alg": alg, "kid": kid}, map[string]interface{}{}, key)
+				if _, err := v.Verify(token); err != nil {
+					t.Fatalf("%s: %v", kid, err)
+				}
+			}
+		})
+	}
+}
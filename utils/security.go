@@ -0,0 +1,51 @@
+package ginapiutil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/anqur/ginapi/utils/detail"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrForbidden fails the request with 403 when wrapped by the errors of the
+// generated `SecurityHandler`, the other errors fail with 401.
+var ErrForbidden = detail.ErrForbidden
+
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// SetScopes grants the scopes to the request, it's called by the
+// authenticators, e.g. with the scopes of the access token.
+func SetScopes(c *gin.Context, scopes ...string) {
+	c.Set(detail.ScopesKey, scopes)
+}
+
+// Scopes returns the scopes granted to the request.
+func Scopes(c *gin.Context) []string {
+	return c.GetStringSlice(detail.ScopesKey)
+}
+
+// RequireScopes rejects the request with 403 if the scopes required by the
+// operation are not all granted by SetScopes, the missing ones are listed in
+// the response. It must be registered as a middleware of the services, e.g.
+// `ginapi.RegisterPetsService(service, ginapiutil.RequireScopes())`, which
+// runs after the authentication. Middlewares of the engine run before that,
+// when no requirement is met yet, and every request would pass.
+func RequireScopes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		missing := detail.MissingScopes(c)
+		if len(missing) == 0 {
+			return
+		}
+
+		scope := strings.Join(missing, " ")
+		c.Header("WWW-Authenticate", fmt.Sprintf("Bearer error=\"insufficient_scope\", scope=%q", scope))
+		_ = c.Error(fmt.Errorf("%w: %s", ErrInsufficientScope, scope))
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":          "insufficient_scope",
+			"missing_scopes": missing,
+		})
+	}
+}